@@ -0,0 +1,137 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// SubscribeBatch subscribes to an event with a handler receiving the queued events
+// in batches rather than one at a time, for example to perform bulk inserts. The
+// type of the event will be automatically inferred from the provided type.
+//
+// The slice handed to the handler is only valid for the duration of the call, as it
+// is reused for the next batch. Handlers that need to retain the events after
+// returning must copy them. The size of the batches and how long to wait for them
+// to fill up can be tuned with the WithMaxBatch and WithMaxWait options.
+func SubscribeBatch[T Event](broker *Dispatcher, handler func([]T), opts ...SubscribeOption) context.CancelFunc {
+	var event T
+	return SubscribeToBatch(broker, event.Type(), handler, opts...)
+}
+
+// SubscribeToBatch subscribes to an event with the specified event type and with a
+// handler receiving the queued events in batches. See SubscribeBatch for details.
+func SubscribeToBatch[T Event](broker *Dispatcher, eventType uint32, handler func([]T), opts ...SubscribeOption) context.CancelFunc {
+	options := newSubscription(opts)
+	options.bulk = handler
+	return subscribe[T](broker, eventType, nil, options)
+}
+
+// linger returns whether the consumer should keep waiting for its batch to fill up
+// before processing it. This must be called while holding the lock of the group.
+func (s *consumer[T]) linger() bool {
+	return s.maxWait > 0 && !s.stop &&
+		(s.maxBatch == 0 || len(s.queue) < s.maxBatch) &&
+		time.Since(s.since) < s.maxWait
+}
+
+// flush hands the events to the batch handler in chunks of at most the maximum
+// batch size. It returns false if the consumer must stop.
+func (s *consumer[T]) flush(g *group[T], batch []message[T]) bool {
+	size := s.maxBatch
+	if size == 0 || size > len(batch) {
+		size = len(batch)
+	}
+
+	for lo := 0; lo < len(batch); lo += size {
+		if s.abort.Load() {
+			s.discard(g, batch[lo:])
+			return false
+		}
+
+		hi := lo + size
+		if hi > len(batch) {
+			hi = len(batch)
+		}
+
+		// Events whose publish context expired are dead-lettered instead
+		chunk := batch[lo:hi]
+		if s.buries() {
+			chunk = s.unexpired(g, chunk)
+		}
+
+		// Complete every event of the chunk with the outcome of the handler
+		var err error
+		var failure *HandlerError
+		if len(chunk) > 0 {
+			failure = s.runBatch(g, chunk)
+		}
+
+		s.handled(g, hi-lo)
+		if failure != nil {
+			err = failure
+			s.failures(g, len(chunk))
+			if onError := g.owner.config.onError; onError != nil {
+				onError(failure)
+			}
+			if s.buries() {
+				for _, msg := range chunk {
+					s.bury(g, msg, failure.Err, 1)
+				}
+			}
+		}
+
+		for i := range chunk {
+			chunk[i].meta.complete(err)
+		}
+
+		s.inflight.Add(-int64(hi - lo))
+		if err != nil && !s.keepAlive {
+			s.discard(g, batch[hi:])
+			g.Drop(s)
+			return false
+		}
+	}
+	return true
+}
+
+// unexpired dead-letters the events of the chunk whose publish context expired,
+// and returns the remaining ones.
+func (s *consumer[T]) unexpired(g *group[T], chunk []message[T]) []message[T] {
+	live := chunk[:0]
+	for i := range chunk {
+		if chunk[i].meta.expired() {
+			s.expire(g, &chunk[i])
+			continue
+		}
+		live = append(live, chunk[i])
+	}
+	return live
+}
+
+// runBatch calls the batch handler with the events of the chunk, recovering from a
+// panic. The reported event of a panic is the first event of the chunk.
+func (s *consumer[T]) runBatch(g *group[T], chunk []message[T]) (failure *HandlerError) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure = newPanicError(g.eventType, s.id, chunk[0].event, r)
+		}
+	}()
+
+	s.events = s.events[:0]
+	for i := range chunk {
+		s.events = append(s.events, chunk[i].event)
+	}
+
+	if g.timing {
+		start := time.Now()
+		defer func() {
+			s.durations.observe(time.Since(start))
+		}()
+	}
+
+	s.bulk(s.events)
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeBatch(t *testing.T) {
+	d := NewDispatcher(WithInterval(10 * time.Millisecond))
+	defer d.Close()
+
+	var mu sync.Mutex
+	var received []int
+	batches := 0
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches++
+		for _, ev := range events {
+			received = append(received, ev.Number)
+		}
+	})()
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 100
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.IsIncreasing(t, received)
+	assert.Less(t, batches, 100)
+}
+
+func TestSubscribeBatchMaxBatch(t *testing.T) {
+	d := NewDispatcher(WithInterval(10 * time.Millisecond))
+	defer d.Close()
+
+	var mu sync.Mutex
+	var sizes []int
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(events))
+	}, WithMaxBatch(10))()
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		total := 0
+		for _, n := range sizes {
+			assert.LessOrEqual(t, n, 10)
+			total += n
+		}
+		return total == 100
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscribeBatchMaxWait(t *testing.T) {
+	d := NewDispatcher(WithInterval(time.Millisecond))
+	defer d.Close()
+
+	out := make(chan int, 10)
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		out <- len(events)
+	}, WithMaxBatch(20), WithMaxWait(time.Minute))()
+
+	// The batch is held back across several flushes, until it is full
+	for i := 0; i < 20; i++ {
+		Publish(d, MyEvent1{Number: i})
+		time.Sleep(200 * time.Microsecond)
+	}
+	assert.Equal(t, 20, <-out)
+}
+
+func TestSubscribeBatchMaxWaitExpired(t *testing.T) {
+	d := NewDispatcher(WithInterval(time.Millisecond))
+	defer d.Close()
+
+	out := make(chan int, 10)
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		out <- len(events)
+	}, WithMaxBatch(100), WithMaxWait(20*time.Millisecond))()
+
+	// The batch never fills up, it is processed once the wait expires
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+	assert.Equal(t, 5, <-out)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestSubscribeBatchSync(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	count := 0
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		count += len(events)
+	})()
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, 1, count)
+}
+
+func TestSubscribeBatchPanic(t *testing.T) {
+	errs := make(chan *HandlerError, 1)
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		errs <- err
+	}))
+	defer d.Close()
+
+	SubscribeBatch(d, func(events []MyEvent1) {
+		panic("boom")
+	})
+
+	err := PublishSync(d, MyEvent1{Number: 42})
+	assert.ErrorContains(t, err, "boom")
+
+	failure := <-errs
+	assert.Equal(t, MyEvent1{Number: 42}, failure.Event)
+	assert.Equal(t, "boom", failure.Panic)
+
+	// Subscriber is dropped after a panic
+	assert.Eventually(t, func() bool {
+		return d.count(TypeEvent1) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscribeBatchConflict(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	assert.Panics(t, func() {
+		SubscribeToBatch(d, TypeEvent1, func(events []MyEvent2) {})
+	})
+}
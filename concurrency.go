@@ -0,0 +1,176 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+)
+
+// dispatch processes a batch of events, handing it over to the workers of the
+// consumer if it has several. It returns false if the consumer must stop.
+func (s *consumer[T]) dispatch(g *group[T], workers *pool[T], batch []message[T]) bool {
+	switch {
+	case s.bulk != nil:
+		return s.flush(g, batch)
+	case workers == nil:
+		return s.drain(g, batch)
+	case !workers.push(batch):
+		s.discard(g, batch)
+		return false
+	default:
+		return true
+	}
+}
+
+// ------------------------------------- Pool -------------------------------------
+
+// pool represents the persistent workers of a consumer, each of them handling a
+// single event at a time so that a slow event only holds up its own worker. The
+// workers share a single lane of events, unless the consumer has an ordering key.
+// In that case, every worker has its own lane and the events are assigned to a
+// lane by their key, preserving their relative order.
+type pool[T Event] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	wg     sync.WaitGroup
+	lanes  []lane[T]      // Events waiting for a worker
+	key    func(T) uint64 // Ordering key of the events, if any
+	size   int            // Number of events waiting, across the lanes
+	limit  int            // Maximum number of events waiting
+	closed bool           // Whether the workers exit once their lane is empty
+	failed bool           // Whether a worker stopped the consumer
+}
+
+// lane represents a queue of events waiting for a worker
+type lane[T Event] struct {
+	events []message[T] // Events, including the ones already taken
+	head   int          // Index of the next event to take
+}
+
+// newPool creates a pool of workers for the consumer and starts them
+func newPool[T Event](sub *consumer[T], g *group[T]) *pool[T] {
+	p := &pool[T]{
+		lanes: make([]lane[T], 1),
+		key:   sub.key,
+		limit: g.maxQueue,
+	}
+
+	p.cond = sync.NewCond(&p.mu)
+	if p.key != nil {
+		p.lanes = make([]lane[T], sub.workers)
+	}
+
+	p.wg.Add(sub.workers)
+	for i := 0; i < sub.workers; i++ {
+		go p.work(sub, g, &p.lanes[i%len(p.lanes)])
+	}
+	return p
+}
+
+// push appends a batch of events to the lanes of the workers, waiting for them
+// to catch up if too many events are waiting already, so that the queue of the
+// consumer applies backpressure. It returns false if the consumer was stopped.
+func (p *pool[T]) push(batch []message[T]) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.size >= p.limit && !p.failed {
+		p.cond.Wait()
+	}
+
+	if p.failed {
+		return false
+	}
+
+	n := uint64(len(p.lanes))
+	for _, msg := range batch {
+		var i uint64
+		if p.key != nil {
+			i = p.key(msg.event) % n
+		}
+		p.lanes[i].events = append(p.lanes[i].events, msg)
+	}
+
+	p.size += len(batch)
+	p.cond.Broadcast()
+	return true
+}
+
+// take waits for the next event of the lane, and returns false once the pool is
+// closed and the lane is empty, or if the consumer was stopped.
+func (p *pool[T]) take(l *lane[T]) (message[T], bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for l.head == len(l.events) {
+		if p.closed || p.failed {
+			return message[T]{}, false
+		}
+		p.cond.Wait()
+	}
+
+	msg := l.events[l.head]
+	l.events[l.head] = message[T]{}
+	if l.head++; l.head == len(l.events) {
+		l.events, l.head = l.events[:0], 0
+	}
+
+	// Wake up the listener, which may be waiting for room
+	p.size--
+	p.cond.Broadcast()
+	return msg, true
+}
+
+// work handles the events of the lane one at a time, until the pool is closed or
+// the consumer stopped.
+func (p *pool[T]) work(sub *consumer[T], g *group[T], l *lane[T]) {
+	defer p.wg.Done()
+	batch := make([]message[T], 1)
+	for {
+		msg, ok := p.take(l)
+		if !ok {
+			return
+		}
+
+		batch[0] = msg
+		ok = sub.drain(g, batch)
+		batch[0] = message[T]{}
+		if !ok {
+			p.fail(sub, g)
+		}
+
+		// Wake up the listener, which waits for the workers before exiting
+		if sub.inflight.Load() <= 0 {
+			g.cond.L.Lock()
+			g.cond.Broadcast()
+			g.cond.L.Unlock()
+		}
+	}
+}
+
+// fail stops the workers once the consumer panicked or was aborted, discarding
+// the events waiting for them.
+func (p *pool[T]) fail(sub *consumer[T], g *group[T]) {
+	var dropped []message[T]
+	p.mu.Lock()
+	p.failed = true
+	for i := range p.lanes {
+		l := &p.lanes[i]
+		dropped = append(dropped, l.events[l.head:]...)
+		l.events, l.head = nil, 0
+	}
+	p.size = 0
+	p.mu.Unlock()
+	p.cond.Broadcast()
+
+	sub.discard(g, dropped)
+	sub.inflight.Add(-int64(len(dropped)))
+}
+
+// close lets the workers handle the remaining events, then waits for them to exit
+func (p *pool[T]) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.wg.Wait()
+}
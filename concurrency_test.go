@@ -0,0 +1,157 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	var running, peak atomic.Int64
+	wg.Add(8)
+	defer Subscribe(d, func(ev MyEvent1) {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		wg.Done()
+	}, WithConcurrency(4))()
+
+	for i := 0; i < 8; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	wg.Wait()
+	assert.Greater(t, peak.Load(), int64(1))
+	assert.LessOrEqual(t, peak.Load(), int64(4))
+}
+
+func TestConcurrencySlowEvent(t *testing.T) {
+	for _, keyed := range []bool{false, true} {
+		d := NewDispatcher()
+		opts := []SubscribeOption{WithConcurrency(4)}
+		if keyed { // Only the first event has a different key
+			opts = append(opts, WithOrderingKey(func(ev MyEvent1) uint64 {
+				if ev.Number == 0 {
+					return 0
+				}
+				return 1
+			}))
+		}
+
+		// The first event blocks its worker until every other event was handled
+		release := make(chan struct{})
+		var count atomic.Int64
+		Subscribe(d, func(ev MyEvent1) {
+			if ev.Number == 0 {
+				<-release
+				return
+			}
+			count.Add(1)
+		}, opts...)
+
+		for i := 0; i <= 40; i++ {
+			Publish(d, MyEvent1{Number: i})
+		}
+
+		assert.Eventually(t, func() bool {
+			return count.Load() == 40
+		}, time.Second, time.Millisecond)
+		close(release)
+		assert.NoError(t, d.Close())
+	}
+}
+
+func TestOrderingKey(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	const keys, events = 5, 1000
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[uint64][]int)
+	wg.Add(events)
+	defer Subscribe(d, func(ev MyEvent1) {
+		mu.Lock()
+		key := uint64(ev.Number % keys)
+		seen[key] = append(seen[key], ev.Number)
+		mu.Unlock()
+		wg.Done()
+	}, WithConcurrency(3), WithOrderingKey(func(ev MyEvent1) uint64 {
+		return uint64(ev.Number % keys)
+	}))()
+
+	for i := 0; i < events; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	// Events sharing the same key must be handled in order
+	wg.Wait()
+	assert.Len(t, seen, keys)
+	for _, numbers := range seen {
+		assert.Len(t, numbers, events/keys)
+		assert.IsIncreasing(t, numbers)
+	}
+}
+
+func TestConcurrencyPanic(t *testing.T) {
+	var failures atomic.Int64
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures.Add(1)
+	}))
+	defer d.Close()
+
+	var count atomic.Int64
+	defer Subscribe(d, func(ev MyEvent1) {
+		if ev.Number%10 == 0 {
+			panic("boom")
+		}
+		count.Add(1)
+	}, WithConcurrency(4), WithKeepAlive())()
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Eventually(t, func() bool {
+		return count.Load() == 90 && failures.Load() == 10
+	}, time.Second, time.Millisecond)
+}
+
+func TestConcurrencySync(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count atomic.Int64
+	defer Subscribe(d, func(ev MyEvent1) {
+		count.Add(1)
+	}, WithConcurrency(4))()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+	}
+	assert.Equal(t, int64(10), count.Load())
+}
+
+func TestOrderingKeyConflict(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	assert.Panics(t, func() {
+		Subscribe(d, func(ev MyEvent1) {}, WithOrderingKey(func(ev MyEvent2) uint64 {
+			return 0
+		}))
+	})
+}
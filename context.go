@@ -0,0 +1,65 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscribeCtx subscribes to an event with a context-aware handler, the type of the
+// event will be automatically inferred from the provided type. The subscription is
+// automatically cancelled once the context is done.
+func SubscribeCtx[T Event](ctx context.Context, broker *Dispatcher, handler func(context.Context, T), opts ...SubscribeOption) context.CancelFunc {
+	var event T
+	return SubscribeToCtx(ctx, broker, event.Type(), handler, opts...)
+}
+
+// SubscribeToCtx subscribes to an event with the specified event type and a context-aware
+// handler. The subscription is automatically cancelled once the context is done.
+//
+// The handler receives the context of the publisher if the event was published with
+// PublishCtx() or PublishWait(), carrying its values and deadline. Otherwise, it
+// receives the context of the subscription.
+func SubscribeToCtx[T Event](ctx context.Context, broker *Dispatcher, eventType uint32, handler func(context.Context, T), opts ...SubscribeOption) context.CancelFunc {
+	options := newSubscription(opts)
+	options.ctx = ctx
+
+	cancel := subscribe(broker, eventType, func(ctx context.Context, m *message[T]) error {
+		handler(ctx, m.event)
+		return nil
+	}, options)
+
+	// Context can never be cancelled, no need to watch it
+	if ctx.Done() == nil {
+		return cancel
+	}
+
+	// Unsubscribe once the context is done, or the subscription is cancelled
+	var once sync.Once
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(stop) })
+		cancel()
+	}
+}
+
+// PublishCtx writes an event into the dispatcher along with the context of the
+// publisher. Context-aware handlers receive this context, which allows values
+// such as request identifiers and deadlines to propagate to the subscribers. The
+// subscribers with dead letters dead-letter the event instead of handling it if
+// the context expires first, see WithDeadLetter.
+func PublishCtx[T Event](ctx context.Context, broker *Dispatcher, ev T) {
+	if group := groupTo[T](broker, ev.Type()); group != nil {
+		group.Publish(message[T]{event: ev, meta: &metadata{ctx: ctx}}, true)
+	}
+}
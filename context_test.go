@@ -0,0 +1,97 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contextKey string
+
+func TestSubscribeCtx(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan context.Context, 10)
+	SubscribeCtx(ctx, d, func(ctx context.Context, ev MyEvent1) {
+		received <- ctx
+	})
+	assert.Equal(t, 1, d.count(TypeEvent1))
+
+	// Without a publisher context, the subscription context is used
+	Publish(d, MyEvent1{})
+	assert.Equal(t, ctx, <-received)
+
+	// Must automatically unsubscribe
+	cancel()
+	assert.Eventually(t, func() bool {
+		return d.count(TypeEvent1) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscribeCtxCancel(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	unsubscribe := SubscribeCtx(context.Background(), d, func(ctx context.Context, ev MyEvent1) {})
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	unsubscribe()
+	assert.Equal(t, 0, d.count(TypeEvent1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	unsubscribe = SubscribeCtx(ctx, d, func(ctx context.Context, ev MyEvent1) {})
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 0, d.count(TypeEvent1))
+}
+
+func TestPublishCtx(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	type result struct {
+		value    any
+		deadline time.Time
+	}
+
+	received := make(chan result, 10)
+	defer SubscribeCtx(context.Background(), d, func(ctx context.Context, ev MyEvent1) {
+		deadline, _ := ctx.Deadline()
+		received <- result{
+			value:    ctx.Value(contextKey("request")),
+			deadline: deadline,
+		}
+	})()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ctx = context.WithValue(ctx, contextKey("request"), "abc")
+	PublishCtx(ctx, d, MyEvent1{})
+
+	out := <-received
+	expect, _ := ctx.Deadline()
+	assert.Equal(t, "abc", out.value)
+	assert.Equal(t, expect, out.deadline)
+}
+
+func TestPublishWaitCtx(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	defer SubscribeCtx(context.Background(), d, func(ctx context.Context, ev MyEvent1) {
+		assert.Equal(t, "abc", ctx.Value(contextKey("request")))
+	})()
+
+	ctx := context.WithValue(context.Background(), contextKey("request"), "abc")
+	assert.NoError(t, PublishWait(ctx, d, MyEvent1{}))
+}
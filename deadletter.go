@@ -0,0 +1,264 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// TypeDeadLetter is the event type of the dead letters, reserved by the dispatcher
+const TypeDeadLetter uint32 = math.MaxUint32
+
+// errExpired is returned internally when an expired event was dead-lettered
+var errExpired = errors.New("event: publish context expired")
+
+// DeadLetter represents an event that a subscriber failed to handle, either because
+// its handler failed, because its publish context expired before it was handled or
+// because it was dropped from the queue of the subscriber. Dead letters are only
+// created for the subscribers with the WithDeadLetter or WithDeadLetterQueue options.
+type DeadLetter struct {
+	Event      Event        // Event that failed to be handled
+	Err        error        // Error of the last attempt, or the reason it was dropped
+	Subscriber uint64       // Identifier of the subscriber
+	Label      string       // Label of the subscriber, if any
+	Attempts   int          // Number of attempts made
+	Seq        uint64       // Dispatcher-wide sequence number of the event, if any
+	Time       time.Time    // Time at which the event was dead-lettered
+	redrive    func() error // Queues the event for the subscriber again
+}
+
+// Type returns the event type of the dead letters
+func (DeadLetter) Type() uint32 {
+	return TypeDeadLetter
+}
+
+// Redrive queues the event for the subscriber that failed to handle it again, and
+// only for that subscriber. It returns ErrNoSubscribers if the subscriber has
+// unsubscribed since, ErrClosed if the dispatcher is closed, or ErrDropped if the
+// queue of the subscriber is full and its overflow policy dropped the event.
+func (l DeadLetter) Redrive() error {
+	if l.redrive == nil {
+		return ErrNoSubscribers
+	}
+	return l.redrive()
+}
+
+// buries returns whether the consumer dead-letters the events it fails to handle
+func (s *consumer[T]) buries() bool {
+	return s.deadLetter || s.letters != nil
+}
+
+// bury creates a dead letter for a message the consumer failed to handle, adding
+// it to the dead letter queue of the consumer and publishing it on the dispatcher.
+// It never blocks, so it can be called while holding the lock of the group.
+// Failures of the dead letter subscribers themselves are not dead-lettered again.
+func (s *consumer[T]) bury(g *group[T], msg message[T], err error, attempts int) {
+	if g.eventType == TypeDeadLetter {
+		return
+	}
+
+	kept := msg.detach()
+	letter := DeadLetter{
+		Event:      msg.event,
+		Err:        err,
+		Subscriber: s.id,
+		Label:      s.label,
+		Attempts:   attempts,
+		Seq:        msg.seq,
+		Time:       time.Now(),
+		redrive: func() error {
+			return g.redrive(s, kept)
+		},
+	}
+
+	if s.letters != nil {
+		s.letters.Add(letter)
+	}
+	if s.deadLetter {
+		g.owner.outbox.post(g.owner, letter)
+	}
+}
+
+// expire dead-letters a message whose publish context expired before it was
+// handled, instead of handling it.
+func (s *consumer[T]) expire(g *group[T], msg *message[T]) error {
+	err := msg.meta.ctx.Err()
+	s.bury(g, *msg, err, msg.meta.retries)
+	msg.meta.complete(err)
+	return errExpired
+}
+
+// expired returns whether the publish context of the message has expired
+func (m *metadata) expired() bool {
+	return m != nil && m.ctx != nil && m.ctx.Err() != nil
+}
+
+// attempts returns the number of attempts already made for the message
+func (m *metadata) attempts() int {
+	if m == nil {
+		return 0
+	}
+	return m.retries
+}
+
+// redrive queues the message for the consumer again, going through its overflow
+// policy like any published message. It returns ErrDropped if the message was
+// dropped, in which case it is dead-lettered again.
+func (s *group[T]) redrive(sub *consumer[T], msg message[T]) (err error) {
+	s.cond.L.Lock()
+	switch {
+	case s.closed:
+		s.cond.L.Unlock()
+		return ErrClosed
+	case sub.stop:
+		s.cond.L.Unlock()
+		return ErrNoSubscribers
+	}
+
+	var evicted []*consumer[T]
+	if s.deliver(sub, msg, &evicted) == 0 {
+		err = ErrDropped
+	}
+
+	s.expel(evicted)
+	s.cond.Broadcast()
+	return
+}
+
+// ------------------------------------- Outbox -------------------------------------
+
+// outbox represents the dead letters waiting to be published on the dispatcher.
+// They are published by a single goroutine without blocking, so that the consumers
+// burying them are never held back by the dead letter subscribers.
+type outbox struct {
+	mu      sync.Mutex
+	letters []DeadLetter // Dead letters waiting to be published
+	running bool         // Whether a goroutine is publishing them
+}
+
+// post queues the dead letter for publishing, starting the publishing goroutine
+// if it is not running yet.
+func (o *outbox) post(d *Dispatcher, letter DeadLetter) {
+	o.mu.Lock()
+	o.letters = append(o.letters, letter)
+	start := !o.running
+	o.running = true
+	o.mu.Unlock()
+
+	if start {
+		go o.publish(d)
+	}
+}
+
+// publish publishes the queued dead letters until there are none left. A dead
+// letter that can not be published, for example because the queue of a dead
+// letter subscriber is full, is reported to the error handler of the dispatcher.
+func (o *outbox) publish(d *Dispatcher) {
+	for {
+		o.mu.Lock()
+		letters := o.letters
+		o.letters = nil
+		if len(letters) == 0 {
+			o.running = false
+			o.mu.Unlock()
+			return
+		}
+		o.mu.Unlock()
+
+		for _, letter := range letters {
+			switch err := TryPublish(d, letter); err {
+			case nil, ErrNoSubscribers, ErrClosed:
+			default:
+				if onError := d.config.onError; onError != nil {
+					onError(&HandlerError{
+						Type:  TypeDeadLetter,
+						Event: letter,
+						Err:   err,
+					})
+				}
+			}
+		}
+	}
+}
+
+// ------------------------------------- Dead Letters -------------------------------------
+
+// DeadLetters represents a bounded, in-memory store of dead letters. Once full, the
+// oldest dead letters are discarded. It can be given to subscribers directly with
+// WithDeadLetterQueue, or subscribed to the dead letters published on a dispatcher.
+type DeadLetters struct {
+	mu      sync.Mutex
+	letters []DeadLetter // Stored dead letters, from the oldest
+	limit   int          // Maximum number of dead letters
+}
+
+// NewDeadLetters creates a new store keeping up to the specified number of dead
+// letters.
+func NewDeadLetters(size int) *DeadLetters {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &DeadLetters{limit: size}
+}
+
+// Add stores the dead letter, discarding the oldest one if the store is full
+func (q *DeadLetters) Add(letter DeadLetter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.letters) >= q.limit {
+		copy(q.letters, q.letters[1:])
+		q.letters = q.letters[:len(q.letters)-1]
+	}
+
+	q.letters = append(q.letters, letter)
+}
+
+// Len returns the number of stored dead letters
+func (q *DeadLetters) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.letters)
+}
+
+// List returns a copy of the stored dead letters, from the oldest to the newest
+func (q *DeadLetters) List() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]DeadLetter(nil), q.letters...)
+}
+
+// Redrive redrives the stored dead letters matching the predicate, or all of them
+// if the predicate is nil, and removes them from the store. It returns the number
+// of dead letters redriven, those that could not be redriven are discarded.
+func (q *DeadLetters) Redrive(predicate func(DeadLetter) bool) (count int) {
+	q.mu.Lock()
+	var redrive []DeadLetter
+	kept := q.letters[:0]
+	for _, letter := range q.letters {
+		if predicate == nil || predicate(letter) {
+			redrive = append(redrive, letter)
+		} else {
+			kept = append(kept, letter)
+		}
+	}
+
+	// Clear the tail, so the removed dead letters can be collected
+	for i := len(kept); i < len(q.letters); i++ {
+		q.letters[i] = DeadLetter{}
+	}
+	q.letters = kept
+	q.mu.Unlock()
+
+	// Redrive outside of the lock, as the subscribers may fail again
+	for _, letter := range redrive {
+		if letter.Redrive() == nil {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,282 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetter(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	letters := NewDeadLetters(10)
+	defer Subscribe(d, letters.Add)()
+
+	// The handler fails until it is redriven
+	errBroken := errors.New("broken")
+	var broken atomic.Bool
+	var calls atomic.Int32
+	broken.Store(true)
+	out := make(chan int, 10)
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		calls.Add(1)
+		if broken.Load() {
+			return errBroken
+		}
+		out <- ev.Number
+		return nil
+	}, WithDeadLetter(), WithLabel("orders"), WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}))()
+
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{Number: 1}), errBroken)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Eventually(t, func() bool {
+		return letters.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	// Inspect the dead letter
+	letter := letters.List()[0]
+	assert.Equal(t, MyEvent1{Number: 1}, letter.Event)
+	assert.ErrorIs(t, letter.Err, errBroken)
+	assert.Equal(t, "orders", letter.Label)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Zero(t, letter.Seq) // Nothing needed the event to be sequenced
+	assert.NotZero(t, letter.Subscriber)
+
+	// Redrive it once the handler is fixed
+	broken.Store(false)
+	assert.Equal(t, 1, letters.Redrive(nil))
+	assert.Equal(t, 0, letters.Len())
+	assert.Equal(t, 1, <-out)
+}
+
+func TestDeadLetterBounded(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	letters := NewDeadLetters(2)
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		return errors.New("failed")
+	}, WithDeadLetterQueue(letters))()
+
+	for i := 1; i <= 3; i++ {
+		PublishSync(d, MyEvent1{Number: i})
+	}
+
+	// Only the most recent dead letters are kept
+	assert.Eventually(t, func() bool {
+		list := letters.List()
+		return len(list) == 2 && list[1].Event.(MyEvent1).Number == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, MyEvent1{Number: 2}, letters.List()[0].Event)
+	assert.Equal(t, 1, letters.List()[0].Attempts)
+
+	// Redrive only the matching dead letters
+	assert.Equal(t, 1, letters.Redrive(func(l DeadLetter) bool {
+		return l.Event.(MyEvent1).Number == 3
+	}))
+	assert.Eventually(t, func() bool {
+		return letters.Len() == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestDeadLetterPanic(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	out := make(chan DeadLetter, 10)
+	defer Subscribe(d, func(l DeadLetter) {
+		out <- l
+	})()
+
+	var calls atomic.Int32
+	cancel := Subscribe(d, func(ev MyEvent1) {
+		calls.Add(1)
+		panic("boom")
+	}, WithDeadLetter(), WithKeepAlive(), WithRetry(RetryPolicy{MaxAttempts: 3}))
+
+	// Panics are not retried
+	Publish(d, MyEvent1{Number: 1})
+	letter := <-out
+	assert.Equal(t, 1, letter.Attempts)
+	assert.EqualError(t, letter.Err, "boom")
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The subscriber is gone, so it can no longer be redriven
+	cancel()
+	assert.ErrorIs(t, letter.Redrive(), ErrNoSubscribers)
+	assert.ErrorIs(t, DeadLetter{}.Redrive(), ErrNoSubscribers)
+}
+
+func TestDeadLetterExpired(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	letters := NewDeadLetters(10)
+	var calls atomic.Int32
+	defer Subscribe(d, func(ev MyEvent1) {
+		calls.Add(1)
+	}, WithDeadLetterQueue(letters))()
+
+	// The event expired before being handled, so it is dead-lettered instead
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	PublishCtx(ctx, d, MyEvent1{Number: 1})
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 1, letters.Len())
+	assert.ErrorIs(t, letters.List()[0].Err, context.Canceled)
+	assert.Equal(t, 0, letters.List()[0].Attempts)
+}
+
+func TestDeadLetterDropped(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	defer d.Close()
+
+	letters := NewDeadLetters(10)
+	release := make(chan struct{})
+	defer Subscribe(d, func(ev MyEvent1) {
+		<-release
+	}, WithOverflow(OverflowDropNewest), WithDeadLetterQueue(letters))()
+
+	// Events that do not fit in the queue are dead-lettered
+	for i := 1; i <= 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+	close(release)
+
+	assert.Greater(t, letters.Len(), 0)
+	letter := letters.List()[0]
+	assert.ErrorIs(t, letter.Err, ErrDropped)
+	assert.Equal(t, 0, letter.Attempts)
+}
+
+func TestDeadLetterAbandoned(t *testing.T) {
+	d := NewDispatcher()
+	letters := NewDeadLetters(10)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	defer close(release)
+	Subscribe(d, func(ev MyEvent1) {
+		started <- struct{}{}
+		<-release
+	}, WithDeadLetterQueue(letters))
+
+	// The first event is being handled while the others are queued
+	Publish(d, MyEvent1{Number: 1})
+	<-started
+	Publish(d, MyEvent1{Number: 2})
+	Publish(d, MyEvent1{Number: 3})
+
+	// The events abandoned on shutdown are dead-lettered
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	abandoned, err := d.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, abandoned)
+	assert.Equal(t, 2, letters.Len())
+	assert.Equal(t, MyEvent1{Number: 2}, letters.List()[0].Event)
+	assert.ErrorIs(t, letters.List()[0].Err, ErrDropped)
+}
+
+func TestDeadLetterNonBlocking(t *testing.T) {
+	failures := make(chan *HandlerError, 1000)
+	d := NewDispatcher(WithMaxQueue(1), WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	// The dead letter subscriber is stuck
+	release := make(chan struct{})
+	defer close(release)
+	defer Subscribe(d, func(l DeadLetter) {
+		<-release
+	})()
+
+	// The failing subscriber is not held back by the dead letter subscriber
+	var calls atomic.Int32
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		calls.Add(1)
+		return errors.New("failed")
+	}, WithDeadLetter())()
+	for i := 1; i <= 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 100
+	}, time.Second, time.Millisecond)
+
+	// Dead letters that could not be published are reported instead
+	assert.Eventually(t, func() bool {
+		for {
+			select {
+			case err := <-failures:
+				if err.Type == TypeDeadLetter && errors.Is(err, ErrFull) {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func TestDeadLetterConflict(t *testing.T) {
+	failures := make(chan *HandlerError, 10)
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	// The dead letter type is registered with a different type
+	defer SubscribeTo(d, TypeDeadLetter, func(ev MyEvent2) {})()
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		return errors.New("failed")
+	}, WithDeadLetter())()
+
+	PublishSync(d, MyEvent1{Number: 1})
+	handler := <-failures
+	assert.Nil(t, handler.Panic)
+	assert.NotZero(t, handler.Subscriber)
+
+	// The conflict is reported as a failure to publish the dead letter
+	published := <-failures
+	assert.ErrorIs(t, published, ErrConflict)
+	assert.Zero(t, published.Subscriber)
+}
+
+func TestDeadLetterBatch(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	letters := NewDeadLetters(10)
+	defer SubscribeBatch(d, func(events []MyEvent1) {
+		panic("boom")
+	}, WithDeadLetterQueue(letters), WithKeepAlive())()
+
+	assert.Error(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, 1, letters.Len())
+	assert.EqualError(t, letters.List()[0].Err, "boom")
+}
+
+func TestDeadLetterPriority(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	_, err := TrySubscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithDeadLetter())
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = TrySubscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithDeadLetterQueue(NewDeadLetters(1)))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+// Package debug provides an HTTP handler to inspect a live event dispatcher, listing
+// its topics and streaming the events of a chosen type.
+package debug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kelindar/event"
+)
+
+// BufferSize is the number of events buffered for each stream, beyond which the
+// oldest events are dropped so that a slow client never slows down the dispatcher.
+const BufferSize = 256
+
+// Label is the label of the temporary subscribers streaming the events
+const Label = "debug"
+
+// message represents an event streamed to a client
+type message struct {
+	Type  uint32      `json:"type"`
+	Name  string      `json:"name"`
+	Event event.Event `json:"event"`
+}
+
+// Handler returns an HTTP handler to inspect the dispatcher. Without parameters,
+// it responds with the topics of the dispatcher and their subscribers as JSON.
+// With a "type" query parameter, for example "?type=0x10", it streams the events
+// of that type as JSON using Server-Sent Events, until the client disconnects, or
+// responds with 503 Service Unavailable if the dispatcher is closed.
+func Handler(dispatcher *event.Dispatcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("type") {
+			stream(dispatcher, w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dispatcher.Topics())
+	})
+}
+
+// stream streams the events of the requested type as Server-Sent Events
+func stream(dispatcher *event.Dispatcher, w http.ResponseWriter, r *http.Request) {
+	eventType, err := strconv.ParseUint(r.URL.Query().Get("type"), 0, 32)
+	if err != nil {
+		http.Error(w, "invalid event type", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Temporary wildcard subscriber, dropping the oldest events instead of blocking
+	events := make(chan event.Event, BufferSize)
+	cancel, err := event.TrySubscribeRange(dispatcher, uint32(eventType), uint32(eventType), func(ev event.Event) {
+		push(events, ev)
+	}, event.WithOverflow(event.OverflowDropOldest), event.WithLabel(Label))
+	switch {
+	case errors.Is(err, event.ErrClosed):
+		http.Error(w, "dispatcher is closed", http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-events:
+			data, err := json.Marshal(message{
+				Type:  ev.Type(),
+				Name:  fmt.Sprintf("%T", ev),
+				Event: ev,
+			})
+			if err != nil {
+				data, _ = json.Marshal(err.Error())
+			}
+
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// push adds the event to the buffer, dropping the oldest event if it is full
+func push(buffer chan event.Event, ev event.Event) {
+	for {
+		select {
+		case buffer <- ev:
+			return
+		default:
+			select {
+			case <-buffer:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package debug
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type Order struct {
+	ID int `json:"id"`
+}
+
+func (Order) Type() uint32 { return 0x10 }
+
+func TestTopics(t *testing.T) {
+	d := event.NewDispatcher()
+	defer d.Close()
+	defer event.Subscribe(d, func(ev Order) {}, event.WithLabel("billing"))()
+
+	server := httptest.NewServer(Handler(d))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var topics []event.TopicInfo
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&topics))
+	assert.Len(t, topics, 1)
+	assert.Equal(t, uint32(0x10), topics[0].Type)
+	assert.Equal(t, "debug.Order", topics[0].Name)
+	assert.Equal(t, "billing", topics[0].Subscribers[0].Label)
+}
+
+func TestStream(t *testing.T) {
+	d := event.NewDispatcher()
+	defer d.Close()
+	defer event.Subscribe(d, func(ev Order) {})()
+
+	server := httptest.NewServer(Handler(d))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?type=0x10", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The response is only sent once the temporary subscriber is attached
+	assert.Len(t, d.Topics()[0].Subscribers, 2)
+	assert.Equal(t, Label, d.Topics()[0].Subscribers[1].Label)
+
+	event.Publish(d, Order{ID: 1})
+	event.Publish(d, Order{ID: 2})
+
+	reader := bufio.NewReader(resp.Body)
+	for _, want := range []string{
+		`data: {"type":16,"name":"debug.Order","event":{"id":1}}`,
+		`data: {"type":16,"name":"debug.Order","event":{"id":2}}`,
+	} {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, want, strings.TrimSpace(line))
+
+		blank, _ := reader.ReadString('\n')
+		assert.Equal(t, "\n", blank)
+	}
+
+	// Once the client disconnects, the temporary subscriber is removed
+	cancel()
+	assert.Eventually(t, func() bool {
+		return len(d.Topics()[0].Subscribers) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestStreamInvalid(t *testing.T) {
+	d := event.NewDispatcher()
+	defer d.Close()
+
+	rec := httptest.NewRecorder()
+	Handler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?type=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestStreamClosed(t *testing.T) {
+	d := event.NewDispatcher()
+	d.Close()
+
+	rec := httptest.NewRecorder()
+	Handler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?type=0x10", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestPush(t *testing.T) {
+	buffer := make(chan event.Event, 2)
+	push(buffer, Order{ID: 1})
+	push(buffer, Order{ID: 2})
+	push(buffer, Order{ID: 3})
+
+	assert.Equal(t, Order{ID: 2}, <-buffer)
+	assert.Equal(t, Order{ID: 3}, <-buffer)
+}
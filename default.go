@@ -13,14 +13,20 @@ var Default = NewDispatcher()
 // On subscribes to an event, the type of the event will be automatically
 // inferred from the provided type. Must be constant for this to work. This
 // functions same way as Subscribe() but uses the default dispatcher instead.
-func On[T Event](handler func(T)) context.CancelFunc {
-	return Subscribe(Default, handler)
+func On[T Event](handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return Subscribe(Default, handler, opts...)
+}
+
+// TryOn subscribes to an event without panicking. This functions same way as
+// TrySubscribe() but uses the default dispatcher instead.
+func TryOn[T Event](handler func(T), opts ...SubscribeOption) (context.CancelFunc, error) {
+	return TrySubscribe(Default, handler, opts...)
 }
 
 // OnType subscribes to an event with the specified event type. This functions
 // same way as SubscribeTo() but uses the default dispatcher instead.
-func OnType[T Event](eventType uint32, handler func(T)) context.CancelFunc {
-	return SubscribeTo(Default, eventType, handler)
+func OnType[T Event](eventType uint32, handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return SubscribeTo(Default, eventType, handler, opts...)
 }
 
 // Emit writes an event into the dispatcher. This functions same way as
@@ -28,3 +34,9 @@ func OnType[T Event](eventType uint32, handler func(T)) context.CancelFunc {
 func Emit[T Event](ev T) {
 	Publish(Default, ev)
 }
+
+// TryEmit writes an event into the dispatcher without blocking. This functions
+// same way as TryPublish() but uses the default dispatcher instead.
+func TryEmit[T Event](ev T) error {
+	return TryPublish(Default, ev)
+}
@@ -52,3 +52,29 @@ func TestDefaultPublish(t *testing.T) {
 	wg.Wait()
 	assert.Equal(t, int64(4), count)
 }
+
+func TestDefaultTryEmit(t *testing.T) {
+	assert.ErrorIs(t, TryEmit(MyEvent2{}), ErrNoSubscribers)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer On(func(ev MyEvent2) {
+		wg.Done()
+	})()
+
+	assert.NoError(t, TryEmit(MyEvent2{}))
+	wg.Wait()
+}
+
+func TestDefaultTryOn(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	cancel, err := TryOn(func(ev MyEvent2) {
+		wg.Done()
+	})
+	assert.NoError(t, err)
+	defer cancel()
+
+	assert.NoError(t, TryEmit(MyEvent2{}))
+	wg.Wait()
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Envelope represents an event along with the metadata assigned when it was
+// published. The headers are shared between all of the subscribers and must
+// not be modified.
+type Envelope[T Event] struct {
+	Seq     uint64            // Dispatcher-wide sequence number
+	Time    time.Time         // Time at which the event was published
+	Source  string            // Source of the event, as provided by the publisher
+	Headers map[string]string // Headers of the event, as provided by the publisher
+	Event   T                 // The event itself
+}
+
+// newEnvelope creates an envelope from a queued message
+func newEnvelope[T Event](m *message[T]) Envelope[T] {
+	env := Envelope[T]{
+		Seq:   m.seq,
+		Event: m.event,
+	}
+
+	if m.time != 0 {
+		env.Time = time.Unix(0, m.time)
+	}
+
+	if m.meta != nil {
+		env.Source = m.meta.source
+		env.Headers = m.meta.headers
+	}
+	return env
+}
+
+// SubscribeEnvelope subscribes to an event and receives it wrapped in an envelope
+// with its metadata. The type of the event will be automatically inferred from the
+// provided type. Must be constant for this to work.
+func SubscribeEnvelope[T Event](broker *Dispatcher, handler func(Envelope[T]), opts ...SubscribeOption) context.CancelFunc {
+	var event T
+	return SubscribeToEnvelope(broker, event.Type(), handler, opts...)
+}
+
+// SubscribeToEnvelope subscribes to an event with the specified event type and
+// receives it wrapped in an envelope with its metadata.
+func SubscribeToEnvelope[T Event](broker *Dispatcher, eventType uint32, handler func(Envelope[T]), opts ...SubscribeOption) context.CancelFunc {
+	options := newSubscription(opts)
+	options.stamp = true
+
+	return subscribe(broker, eventType, func(_ context.Context, m *message[T]) error {
+		handler(newEnvelope(m))
+		return nil
+	}, options)
+}
+
+// PublishEnvelope writes an event into the dispatcher along with its source and
+// headers. The sequence number and the time of the envelope are assigned by the
+// dispatcher, and any value provided for them is ignored.
+func PublishEnvelope[T Event](broker *Dispatcher, env Envelope[T]) {
+	if group := groupTo[T](broker, env.Event.Type()); group != nil {
+		group.Publish(message[T]{event: env.Event, meta: &metadata{
+			source:  env.Source,
+			headers: env.Headers,
+		}}, true)
+	}
+}
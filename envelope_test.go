@@ -0,0 +1,81 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeEnvelope(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	received := make(chan Envelope[MyEvent1], 10)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {
+		received <- env
+	})()
+
+	start := time.Now()
+	Publish(d, MyEvent1{Number: 1})
+	PublishEnvelope(d, Envelope[MyEvent1]{
+		Seq:     42,
+		Source:  "billing",
+		Headers: map[string]string{"tenant": "acme"},
+		Event:   MyEvent1{Number: 2},
+	})
+
+	env1, env2 := <-received, <-received
+	assert.Equal(t, MyEvent1{Number: 1}, env1.Event)
+	assert.Equal(t, MyEvent1{Number: 2}, env2.Event)
+	assert.Empty(t, env1.Source)
+	assert.Nil(t, env1.Headers)
+	assert.Equal(t, "billing", env2.Source)
+	assert.Equal(t, "acme", env2.Headers["tenant"])
+
+	// Sequence and time are assigned by the dispatcher
+	assert.Equal(t, env1.Seq+1, env2.Seq)
+	assert.False(t, env1.Time.Before(start))
+	assert.False(t, env2.Time.Before(env1.Time))
+}
+
+func TestEnvelopeSequence(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	events := make(chan Envelope[MyEvent1], 10)
+	others := make(chan Envelope[MyEvent2], 10)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) { events <- env })()
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent2]) { others <- env })()
+
+	// Sequence numbers are shared across event types
+	Publish(d, MyEvent1{})
+	Publish(d, MyEvent2{})
+	Publish(d, MyEvent1{})
+
+	first, second, third := <-events, <-others, <-events
+	assert.Less(t, first.Seq, second.Seq)
+	assert.Less(t, second.Seq, third.Seq)
+}
+
+func TestEnvelopeStamps(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// Plain subscribers do not need the publish time
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	grp := d.findGroup(TypeEvent1).(*group[MyEvent1])
+	assert.Equal(t, 0, grp.stamps)
+
+	cancel := SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {})
+	assert.Equal(t, 1, grp.stamps)
+	cancel()
+	assert.Equal(t, 0, grp.stamps)
+
+	// Plain events are not sequenced either
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+	assert.Equal(t, uint64(0), d.seq.Load())
+}
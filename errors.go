@@ -0,0 +1,66 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// Various errors returned by the dispatcher
+var (
+	ErrClosed        = errors.New("event: dispatcher is closed")
+	ErrFull          = errors.New("event: subscriber queue is full")
+	ErrNoSubscribers = errors.New("event: no subscribers for the event type")
+	ErrConflict      = errors.New("event: conflicting event type")
+	ErrDropped       = errors.New("event: event was dropped before being processed")
+	ErrUnsupported   = errors.New("event: unsupported combination of options")
+)
+
+// HandlerError represents a failure of an event handler, either an error returned
+// by the handler or a panic recovered while processing an event.
+type HandlerError struct {
+	Type       uint32 // Type of the event
+	Subscriber uint64 // Identifier of the subscriber, zero if the event was not published
+	Event      Event  // Event that failed to be processed
+	Err        error  // Error returned, or the panic converted to an error
+	Panic      any    // Recovered panic value, nil if the handler returned an error
+	Stack      []byte // Stack trace of the panic, nil if the handler returned an error
+	Attempts   int    // Number of attempts made, if the subscriber retries failures
+}
+
+// newPanicError creates a handler error from a recovered panic
+func newPanicError(eventType uint32, subscriber uint64, ev Event, r any) *HandlerError {
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+
+	return &HandlerError{
+		Type:       eventType,
+		Subscriber: subscriber,
+		Event:      ev,
+		Err:        err,
+		Panic:      r,
+		Stack:      debug.Stack(),
+	}
+}
+
+// Error returns the error message
+func (e *HandlerError) Error() string {
+	switch {
+	case e.Subscriber == 0:
+		return fmt.Sprintf("event: unable to publish event 0x%x: %v", e.Type, e.Err)
+	case e.Panic != nil:
+		return fmt.Sprintf("event: handler of subscriber %d panicked on event 0x%x: %v", e.Subscriber, e.Type, e.Err)
+	default:
+		return fmt.Sprintf("event: handler of subscriber %d failed on event 0x%x: %v", e.Subscriber, e.Type, e.Err)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerPanic(t *testing.T) {
+	failures := make(chan *HandlerError, 10)
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	Subscribe(d, func(ev MyEvent1) {
+		panic("boom")
+	})
+
+	Publish(d, MyEvent1{Number: 1})
+	err := <-failures
+	assert.Equal(t, uint32(TypeEvent1), err.Type)
+	assert.NotZero(t, err.Subscriber)
+	assert.Equal(t, "boom", err.Panic)
+	assert.Equal(t, MyEvent1{Number: 1}, err.Event)
+	assert.NotEmpty(t, err.Stack)
+	assert.Contains(t, err.Error(), "panicked")
+
+	// The subscriber must have been removed
+	assert.Eventually(t, func() bool {
+		return d.count(TypeEvent1) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestHandlerPanicKeepAlive(t *testing.T) {
+	var failed atomic.Int32
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failed.Add(1)
+	}))
+	defer d.Close()
+
+	var processed atomic.Int32
+	defer Subscribe(d, func(ev MyEvent1) {
+		processed.Add(1)
+		if ev.Number%2 == 0 {
+			panic("even")
+		}
+	}, WithKeepAlive())()
+
+	for i := 1; i <= 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 10 && failed.Load() == 5
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 1, d.count(TypeEvent1))
+}
+
+func TestHandlerPanicNoHandler(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	Subscribe(d, func(ev MyEvent1) {
+		panic(errors.New("boom"))
+	})
+
+	Publish(d, MyEvent1{})
+	assert.Eventually(t, func() bool {
+		return d.count(TypeEvent1) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestHandlerError(t *testing.T) {
+	errFailed := errors.New("failed")
+	failures := make(chan *HandlerError, 10)
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if ev.Number == 2 {
+			return errFailed
+		}
+		return nil
+	})()
+
+	Publish(d, MyEvent1{Number: 1})
+	Publish(d, MyEvent1{Number: 2})
+	Publish(d, MyEvent1{Number: 3})
+
+	err := <-failures
+	assert.ErrorIs(t, err, errFailed)
+	assert.Nil(t, err.Panic)
+	assert.Nil(t, err.Stack)
+	assert.Equal(t, MyEvent1{Number: 2}, err.Event)
+	assert.Contains(t, err.Error(), "failed")
+
+	// Errors do not unsubscribe the handler
+	assert.Equal(t, 1, d.count(TypeEvent1))
+}
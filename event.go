@@ -19,6 +19,16 @@ type Event interface {
 	Type() uint32
 }
 
+// topic represents a type-erased group of subscribers for a single event type
+type topic interface {
+	Count() int
+	Close()
+	Abandon() int
+	Stats() TypeStats
+	Info() TopicInfo
+	Attach(handler func(context.Context, Event) error, options subscription) context.CancelFunc
+}
+
 // registry holds an immutable sorted array of event mappings
 type registry struct {
 	keys []uint32 // Event types (sorted)
@@ -29,34 +39,83 @@ type registry struct {
 
 // Dispatcher represents an event dispatcher.
 type Dispatcher struct {
-	subs     atomic.Pointer[registry] // Atomic pointer to immutable array
-	done     chan struct{}            // Cancellation
-	df       time.Duration            // Flush interval
-	maxQueue int                      // Maximum queue size per consumer
-	mu       sync.Mutex               // Only for writes (subscribe/unsubscribe)
+	subs       atomic.Pointer[registry]    // Atomic pointer to immutable array
+	done       chan struct{}               // Cancellation
+	config     config                      // Dispatcher configuration
+	nextID     atomic.Uint64               // Sequence for subscriber identifiers
+	seq        atomic.Uint64               // Sequence for published events
+	wild       atomic.Pointer[[]*wildcard] // Wildcard subscriptions (immutable)
+	middleware atomic.Pointer[middleware]  // Interceptors (immutable)
+	active     sync.WaitGroup              // Running consumer goroutines
+	outbox     outbox                      // Dead letters waiting to be published
+	mu         sync.Mutex                  // Only for writes (subscribe/unsubscribe)
 }
 
 // NewDispatcher creates a new dispatcher of events.
-func NewDispatcher() *Dispatcher {
+func NewDispatcher(opts ...Option) *Dispatcher {
 	d := &Dispatcher{
-		df:       500 * time.Microsecond,
-		done:     make(chan struct{}),
-		maxQueue: 50000, // 50k * 20 (df) = 1 million events / second
+		done:   make(chan struct{}),
+		config: newConfig(opts),
 	}
 
 	d.subs.Store(&registry{
 		keys: make([]uint32, 0, 16),
 		grps: make([]any, 0, 16),
 	})
+	d.wild.Store(&[]*wildcard{})
+
+	// Continue the sequence of the journal, so the sequence numbers stay unique
+	if journal := d.config.journal; journal != nil {
+		d.seq.Store(journal.Last())
+	}
 	return d
 }
 
-// Close closes the dispatcher
+// Close closes the dispatcher. It stops accepting new events and subscriptions,
+// while subscribers continue processing their queued events in the background.
 func (d *Dispatcher) Close() error {
-	close(d.done)
+	d.stop()
 	return nil
 }
 
+// Shutdown gracefully shuts down the dispatcher. It stops accepting new events and
+// subscriptions, then waits for every subscriber to process its queued events. If
+// the context expires first, the remaining queued events are discarded and their
+// count is returned along with the context error.
+func (d *Dispatcher) Shutdown(ctx context.Context) (abandoned int, err error) {
+	d.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		d.active.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return 0, nil
+	case <-ctx.Done():
+		for _, grp := range d.subs.Load().grps {
+			abandoned += grp.(topic).Abandon()
+		}
+		return abandoned, ctx.Err()
+	}
+}
+
+// stop stops the dispatcher and signals all of the subscribers to drain their queues
+func (d *Dispatcher) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.isClosed() {
+		return
+	}
+
+	close(d.done)
+	for _, grp := range d.subs.Load().grps {
+		grp.(topic).Close()
+	}
+}
+
 // isClosed returns whether the dispatcher is closed or not
 func (d *Dispatcher) isClosed() bool {
 	select {
@@ -91,31 +150,118 @@ func (d *Dispatcher) findGroup(eventType uint32) any {
 
 // Subscribe subscribes to an event, the type of the event will be automatically
 // inferred from the provided type. Must be constant for this to work.
-func Subscribe[T Event](broker *Dispatcher, handler func(T)) context.CancelFunc {
+func Subscribe[T Event](broker *Dispatcher, handler func(T), opts ...SubscribeOption) context.CancelFunc {
 	var event T
-	return SubscribeTo(broker, event.Type(), handler)
+	return SubscribeTo(broker, event.Type(), handler, opts...)
 }
 
 // SubscribeTo subscribes to an event with the specified event type.
-func SubscribeTo[T Event](broker *Dispatcher, eventType uint32, handler func(T)) context.CancelFunc {
-	if broker.isClosed() {
-		panic(errClosed)
+func SubscribeTo[T Event](broker *Dispatcher, eventType uint32, handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return subscribe(broker, eventType, func(_ context.Context, m *message[T]) error {
+		handler(m.event)
+		return nil
+	}, newSubscription(opts))
+}
+
+// SubscribeErr subscribes to an event with a handler that may fail. Errors returned
+// by the handler are reported to the error handler of the dispatcher. The type of
+// the event will be automatically inferred from the provided type.
+func SubscribeErr[T Event](broker *Dispatcher, handler func(T) error, opts ...SubscribeOption) context.CancelFunc {
+	var event T
+	return SubscribeToErr(broker, event.Type(), handler, opts...)
+}
+
+// SubscribeToErr subscribes to an event with the specified event type and a handler
+// that may fail. Errors returned by the handler are reported to the error handler
+// of the dispatcher.
+func SubscribeToErr[T Event](broker *Dispatcher, eventType uint32, handler func(T) error, opts ...SubscribeOption) context.CancelFunc {
+	return subscribe(broker, eventType, func(_ context.Context, m *message[T]) error {
+		return handler(m.event)
+	}, newSubscription(opts))
+}
+
+// SubscribeWhere subscribes to an event, only receiving the events that match the
+// predicate. Events are filtered when published, so the events that do not match
+// never occupy the queue of the subscriber. The type of the event will be
+// automatically inferred from the provided type.
+func SubscribeWhere[T Event](broker *Dispatcher, predicate func(T) bool, handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return Subscribe(broker, handler, append(opts, WithFilter(predicate))...)
+}
+
+// TrySubscribe subscribes to an event like Subscribe, but returns an error instead
+// of panicking. It returns ErrClosed if the dispatcher is closed and ErrConflict if
+// the event type is registered with a different type or if a typed option does not
+// match the type of the event.
+func TrySubscribe[T Event](broker *Dispatcher, handler func(T), opts ...SubscribeOption) (context.CancelFunc, error) {
+	var event T
+	return TrySubscribeTo(broker, event.Type(), handler, opts...)
+}
+
+// TrySubscribeTo subscribes to an event with the specified event type like
+// SubscribeTo, but returns an error instead of panicking.
+func TrySubscribeTo[T Event](broker *Dispatcher, eventType uint32, handler func(T), opts ...SubscribeOption) (context.CancelFunc, error) {
+	return trySubscribe(broker, eventType, func(_ context.Context, m *message[T]) error {
+		handler(m.event)
+		return nil
+	}, newSubscription(opts))
+}
+
+// subscribe adds a subscriber with a handler to the group of the event type. It
+// panics if the subscriber can not be added, as this is a programming error.
+func subscribe[T Event](broker *Dispatcher, eventType uint32, handler handlerFunc[T], options subscription) context.CancelFunc {
+	cancel, err := trySubscribe(broker, eventType, handler, options)
+	if err != nil {
+		panic(err)
 	}
+	return cancel
+}
 
+// trySubscribe adds a subscriber with a handler to the group of the event type, or
+// returns an error if the subscriber can not be added.
+func trySubscribe[T Event](broker *Dispatcher, eventType uint32, handler handlerFunc[T], options subscription) (context.CancelFunc, error) {
 	broker.mu.Lock()
 	defer broker.mu.Unlock()
+	if broker.isClosed() {
+		return nil, ErrClosed
+	}
 
-	// Check if group already exists
+	// The event type may already be registered with a different type
 	if existing := broker.findGroup(eventType); existing != nil {
-		grp := groupOf[T](eventType, existing)
-		sub := grp.Add(handler)
-		return func() {
-			grp.Del(sub)
+		if _, ok := existing.(*group[T]); !ok {
+			return nil, errConflict[T](eventType, existing)
 		}
 	}
+
+	grp := groupFor[T](broker, eventType)
+	sub, err := grp.Add(handler, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		grp.Del(sub)
+	}, nil
+}
+
+// groupFor returns the group for the event type, creating and registering a new
+// one if necessary. This must be called while holding the dispatcher lock.
+func groupFor[T Event](broker *Dispatcher, eventType uint32) *group[T] {
+	if existing := broker.findGroup(eventType); existing != nil {
+		return groupOf[T](eventType, existing)
+	}
+
 	// Create new grp
-	grp := &group[T]{cond: sync.NewCond(new(sync.Mutex)), maxQueue: broker.maxQueue}
-	sub := grp.Add(handler)
+	config := broker.config.configFor(eventType)
+	grp := &group[T]{
+		cond:      sync.NewCond(new(sync.Mutex)),
+		owner:     broker,
+		eventType: eventType,
+		maxQueue:  config.maxQueue,
+		capacity:  config.capacity,
+		timing:    config.timing,
+		history:   newHistory[T](config.retains()),
+		sticky:    config.retain,
+	}
 
 	// Copy-on-write: insert new entry in sorted position
 	old := broker.subs.Load()
@@ -144,25 +290,86 @@ func SubscribeTo[T Event](broker *Dispatcher, eventType uint32, handler func(T))
 	broker.subs.Store(newReg)
 
 	// Start processing
-	go grp.Process(broker.df, broker.done)
-	return func() {
-		grp.Del(sub)
+	go grp.Process(config.interval, broker.done)
+
+	// Attach the matching wildcard subscriptions, once the group is fully set up
+	for _, w := range *broker.wild.Load() {
+		if w.matches(eventType) {
+			w.attach(grp)
+		}
 	}
+	return grp
 }
 
-// Publish writes an event into the dispatcher
+// Publish writes an event into the dispatcher. If a subscriber's queue is full, the
+// publisher is blocked until it catches up. Events published after the dispatcher
+// is closed are discarded.
 func Publish[T Event](broker *Dispatcher, ev T) {
-	eventType := ev.Type()
+	if group := groupTo[T](broker, ev.Type()); group != nil {
+		group.Publish(message[T]{event: ev}, true)
+	}
+}
+
+// TryPublish writes an event into the dispatcher without blocking. It returns
+// ErrClosed if the dispatcher is closed, ErrNoSubscribers if nobody is subscribed
+// to the event type, ErrFull if a subscriber's queue is full and ErrConflict if
+// the event type is registered with a different Go type.
+func TryPublish[T Event](broker *Dispatcher, ev T) error {
+	group, err := lookup[T](broker, ev.Type())
+	if err != nil {
+		return err
+	}
+
+	return group.Publish(message[T]{event: ev}, false)
+}
+
+// groupTo returns the group to publish the event type to, or nil if there are no
+// subscribers. It panics if the event type is registered with a different type.
+func groupTo[T Event](broker *Dispatcher, eventType uint32) *group[T] {
 	if sub := broker.findGroup(eventType); sub != nil {
-		group := groupOf[T](eventType, sub)
-		group.Broadcast(ev)
+		return groupOf[T](eventType, sub)
+	}
+
+	if group := matchGroup[T](broker, eventType); group != nil {
+		return group
+	}
+	if group := retainGroup[T](broker, eventType); group != nil {
+		return group
 	}
+	return journalGroup[T](broker, eventType)
+}
+
+// lookup returns the group to publish the event type to, or an error if there is
+// no such group or the event type is registered with a different type.
+func lookup[T Event](broker *Dispatcher, eventType uint32) (*group[T], error) {
+	if broker.isClosed() {
+		return nil, ErrClosed
+	}
+
+	sub := broker.findGroup(eventType)
+	if sub == nil {
+		if group := matchGroup[T](broker, eventType); group != nil {
+			return group, nil
+		}
+		if group := retainGroup[T](broker, eventType); group != nil {
+			return group, nil
+		}
+		if group := journalGroup[T](broker, eventType); group != nil {
+			return group, nil
+		}
+		return nil, ErrNoSubscribers
+	}
+
+	if group, ok := sub.(*group[T]); ok {
+		return group, nil
+	}
+	return nil, errConflict[T](eventType, sub)
 }
 
 // Count counts the number of subscribers, this is for testing only.
 func (d *Dispatcher) count(eventType uint32) int {
 	if group := d.findGroup(eventType); group != nil {
-		return group.(interface{ Count() int }).Count()
+		return group.(topic).Count()
 	}
 	return 0
 }
@@ -178,21 +385,98 @@ func groupOf[T Event](eventType uint32, subs any) *group[T] {
 
 // ------------------------------------- Subscriber -------------------------------------
 
+// handlerFunc represents an internal handler, receiving the queued message
+type handlerFunc[T Event] func(context.Context, *message[T]) error
+
+// message represents a queued event along with its optional metadata
+type message[T Event] struct {
+	event T         // The event to deliver
+	seq   uint64    // Dispatcher-wide sequence number
+	time  int64     // Publish time in unix nanoseconds, only if stamped
+	meta  *metadata // Optional metadata, nil for plain events
+}
+
+// metadata represents optional metadata attached to an event when published
+type metadata struct {
+	ctx     context.Context   // Context of the publisher
+	wait    *waiter           // Waiter for synchronous delivery
+	source  string            // Source of the event
+	headers map[string]string // Headers of the event
+	span    any               // Span context of the publisher, if traced
+	record  []byte            // Encoded event to journal, if journaled
+	retries int               // Attempts already made by the consumer, if retried
+	target  any               // Only member of the chain to deliver to, if any
+}
+
+// complete marks the message as processed by one of the consumers
+func (m *metadata) complete(err error) {
+	if m != nil && m.wait != nil {
+		m.wait.fail(err)
+		m.wait.release()
+	}
+}
+
+// reject marks the message as not delivered to one of the consumers
+func (m *metadata) reject(err error) {
+	if m != nil && m.wait != nil {
+		m.wait.fail(err)
+	}
+}
+
 // consumer represents a consumer with a message queue
 type consumer[T Event] struct {
-	queue []T  // Current work queue
-	stop  bool // Stop signal
+	queue      []message[T]    // Current work queue
+	stop       bool            // Stop signal
+	id         uint64          // Subscriber identifier
+	label      string          // Label of the subscriber, for introspection
+	ctx        context.Context // Default context for the handler
+	handler    handlerFunc[T]  // Event handler
+	stamp      bool            // Whether the consumer needs publish time
+	filter     func(T) bool    // Optional predicate, evaluated before queueing
+	shared     *queueGroup[T]  // Queue group of the consumer, nil for fan-out
+	overflow   Overflow        // Overflow policy when the queue is full
+	onEvict    func()          // Callback when the consumer is evicted
+	keepAlive  bool            // Keep the consumer alive after a panic
+	priority   int             // Priority within the ordered chain of the group
+	chained    bool            // Whether the consumer is a member of the chain
+	internal   bool            // Internal consumer, not intercepted by the middleware
+	workers    int             // Number of concurrent workers
+	key        func(T) uint64  // Optional ordering key for concurrent workers
+	bulk       func([]T)       // Optional batch handler, replaces the handler
+	events     []T             // Reusable slice of events for the batch handler
+	maxBatch   int             // Maximum number of events per batch
+	maxWait    time.Duration   // Maximum time to wait for a batch to fill up
+	since      time.Time       // Time the first event of the batch was queued
+	retry      RetryPolicy     // Retry policy of the failed events
+	deadLetter bool            // Whether dead letters are published on the dispatcher
+	letters    *DeadLetters    // Queue the dead letters are added to, if any
+	pending    retrySet[T]     // Scheduled retries, not yet queued
+	halt       chan struct{}   // Closed to interrupt the retries
+	peak       int             // Highest queue length observed
+	durations  histogram       // Distribution of the handler durations
+	counters                   // Statistics of the consumer
+	inflight   atomic.Int64    // Number of swapped events not yet processed
+	abort      atomic.Bool     // Abort signal, discards the in-flight events
 }
 
 // Listen listens to the event queue and processes events
-func (s *consumer[T]) Listen(c *sync.Cond, fn func(T)) {
-	pending := make([]T, 0, 128)
+func (s *consumer[T]) Listen(g *group[T]) {
+	defer g.owner.active.Done()
+	c := g.cond
+	pending := make([]message[T], 0, g.capacity)
+
+	// Hand the events over to persistent workers if the consumer has several
+	var workers *pool[T]
+	if s.workers > 1 && s.bulk == nil {
+		workers = newPool(s, g)
+		defer workers.close()
+	}
 
 	for {
 		c.L.Lock()
-		for len(s.queue) == 0 {
+		for len(s.queue) == 0 || s.linger() {
 			switch {
-			case s.stop:
+			case s.stop && len(s.pending) == 0 && (workers == nil || s.inflight.Load() <= 0):
 				c.L.Unlock()
 				return
 			default:
@@ -204,23 +488,154 @@ func (s *consumer[T]) Listen(c *sync.Cond, fn func(T)) {
 		temp := s.queue
 		s.queue = pending[:0]
 		pending = temp
+		s.inflight.Add(int64(len(pending)))
 		c.L.Unlock()
 
 		// Outside of the critical section, process the work
-		for _, event := range pending {
-			fn(event)
+		if !s.dispatch(g, workers, pending) {
+			return
+		}
+	}
+}
+
+// drain processes a batch of events and returns false if the consumer must stop,
+// either because its handler panicked or because it was aborted. The in-flight
+// count is decremented once per run rather than once per event, so that the
+// happy path does not pay for an atomic operation on every event.
+func (s *consumer[T]) drain(g *group[T], batch []message[T]) bool {
+	for i := 0; i < len(batch); {
+		n, failure := s.run(g, batch[i:])
+		s.handled(g, n)
+		i += n
+		switch {
+		case failure != nil: // Handler panicked
+			s.fail(g, &batch[i-1], failure)
+			s.inflight.Add(-int64(n))
+			if !s.keepAlive {
+				s.discard(g, batch[i:])
+				g.Drop(s)
+				return false
+			}
+		case i < len(batch): // Aborted, the in-flight events were counted as abandoned
+			s.discard(g, batch[i:])
+			return false
+		default:
+			s.inflight.Add(-int64(n))
+		}
+	}
+	return true
+}
+
+// run processes a batch of messages until the end of the batch, the consumer is
+// aborted or one of the handlers panics. It returns the number of messages that
+// were processed, including the one whose handler panicked. Recovering once per
+// batch rather than once per message keeps the happy path cheap.
+func (s *consumer[T]) run(g *group[T], batch []message[T]) (n int, failure *HandlerError) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure = newPanicError(g.eventType, s.id, batch[n].event, r)
+			n++
+		}
+	}()
+
+	var start time.Time
+	if g.timing {
+		start = time.Now()
+	}
+
+	for ; n < len(batch); n++ {
+		if s.abort.Load() {
+			return n, nil
+		}
+
+		msg := &batch[n]
+		switch err := s.handle(g, msg); err {
+		case nil:
+			msg.meta.complete(nil)
+		case errRetry, errExpired: // Scheduled for another attempt, or dead-lettered
+		default:
+			failure := s.failure(g, msg, err)
+			if failure.Panic != nil {
+				return n + 1, failure // Recovered while retrying
+			}
+			s.fail(g, msg, failure)
+		}
+
+		// Record the duration, reading the clock only once per event
+		if g.timing {
+			now := time.Now()
+			s.durations.observe(now.Sub(start))
+			start = now
 		}
 	}
+	return n, nil
+}
+
+// accepts returns whether the consumer accepts the event
+func (s *consumer[T]) accepts(ev T) bool {
+	return s.filter == nil || s.filter(ev)
+}
+
+// contextOf returns the context to pass to the handler for a message
+func (s *consumer[T]) contextOf(msg *message[T]) context.Context {
+	if msg.meta != nil && msg.meta.ctx != nil {
+		return msg.meta.ctx
+	}
+	return s.ctx
+}
+
+// failure converts the error returned by the handler into a handler error
+func (s *consumer[T]) failure(g *group[T], msg *message[T], err error) *HandlerError {
+	if failure, ok := err.(*HandlerError); ok {
+		return failure
+	}
+
+	return &HandlerError{
+		Type:       g.eventType,
+		Subscriber: s.id,
+		Event:      msg.event,
+		Err:        err,
+	}
+}
+
+// fail reports the failure of a handler and completes the message
+func (s *consumer[T]) fail(g *group[T], msg *message[T], err *HandlerError) {
+	s.failures(g, 1)
+	if onError := g.owner.config.onError; onError != nil {
+		onError(err)
+	}
+	if s.buries() {
+		attempts := err.Attempts
+		if attempts == 0 {
+			attempts = 1
+		}
+		s.bury(g, *msg, err.Err, attempts)
+	}
+	msg.meta.complete(err)
 }
 
 // ------------------------------------- Subscriber Group -------------------------------------
 
 // group represents a consumer group
 type group[T Event] struct {
-	cond     *sync.Cond
-	subs     []*consumer[T]
-	maxQueue int // Maximum queue size per consumer
-	maxLen   int // Current maximum queue length across all consumers
+	cond      *sync.Cond
+	subs      []*consumer[T]
+	shared    []*queueGroup[T] // Queue groups with competing consumers
+	chain     *chain[T]        // Ordered chain of prioritized consumers
+	owner     *Dispatcher      // Owning dispatcher
+	eventType uint32           // Event type of the group
+	maxQueue  int              // Maximum queue size per consumer
+	maxLen    int              // Current maximum queue length across all consumers
+	capacity  int              // Initial queue capacity per consumer
+	stamps    int              // Number of consumers that need the publish time
+	closed    bool             // Whether the group no longer accepts events
+	timing    bool             // Whether handler durations are recorded
+	history   *history[T]      // Retained events, nil if not retained
+	sticky    bool             // Whether new consumers receive the last event
+	published uint64           // Number of events published
+	blocked   time.Duration    // Total time publishers were blocked
+	peak      int              // Highest queue length observed
+	counters                   // Statistics of the group
 }
 
 // Process periodically broadcasts events
@@ -240,62 +655,386 @@ func (s *group[T]) Process(interval time.Duration, done chan struct{}) {
 	}
 }
 
-// Broadcast sends an event to all consumers
-func (s *group[T]) Broadcast(ev T) {
-	s.cond.L.Lock()
-	defer s.cond.L.Unlock()
+// send captures the span context of the publisher if the dispatcher is traced,
+// encodes the event if it is journaled, and broadcasts the message to the consumers
+// of the group.
+func (s *group[T]) send(msg message[T], block bool) error {
+	if tracer := s.owner.config.tracer; tracer != nil {
+		msg.meta = capture(tracer, msg.meta)
+	}
 
-	// Backpressure handling: if any queue is at capacity, wait until consumers can process
-	for s.maxLen >= s.maxQueue {
-		s.maxLen = 0
-		for _, sub := range s.subs {
-			if len(sub.queue) > s.maxLen {
-				s.maxLen = len(sub.queue)
-			}
+	if journal := s.owner.config.journal; journal != nil {
+		record, err := journal.encode(s.eventType, msg.event)
+		switch {
+		case err != nil:
+			return s.unjournaled(msg.event, err)
+		case record == nil:
+		case msg.meta == nil:
+			msg.meta = &metadata{record: record}
+		default:
+			msg.meta.record = record
 		}
+	}
+
+	return s.Broadcast(msg, block)
+}
+
+// Broadcast sends an event to all consumers. If block is set, it waits for the
+// consumers to catch up when a queue is full, otherwise it returns ErrFull.
+func (s *group[T]) Broadcast(msg message[T], block bool) error {
+	s.cond.L.Lock()
+
+	// Backpressure handling: if any blocking queue is at capacity, wait until consumers can process
+	for !s.closed && s.maxLen >= s.maxQueue {
+		s.maxLen = s.pressure(msg.event)
 
 		// If still at capacity after update, wait
 		if s.maxLen >= s.maxQueue {
+			if !block {
+				s.cond.L.Unlock()
+				return ErrFull
+			}
+			start := time.Now()
 			s.cond.Wait()
+			s.blocked += time.Since(start)
+		}
+	}
+
+	// Once closed, consumers are draining and no longer accept events
+	journaled := msg.meta != nil && msg.meta.record != nil
+	switch {
+	case s.closed:
+		s.cond.L.Unlock()
+		return ErrClosed
+	case len(s.subs) == 0 && s.history == nil && !journaled:
+		s.cond.L.Unlock()
+		return ErrNoSubscribers
+	}
+
+	// Assign the sequence number while holding the lock, so the queues are ordered,
+	// but only if a subscriber, the history or the journal needs it so that plain
+	// events do not contend on the dispatcher-wide counter. Journaled events are
+	// assigned their sequence number by the journal, and replayed events keep the
+	// sequence number they were journaled with.
+	if s.stamps > 0 || s.history != nil || journaled {
+		if msg.seq == 0 && !journaled {
+			msg.seq = s.owner.seq.Add(1)
+		}
+		if msg.time == 0 {
+			msg.time = time.Now().UnixNano()
+		}
+	}
+
+	// Journal the event before delivering it, in the order of the sequence
+	if journaled {
+		if err := s.journal(&msg); err != nil {
+			s.cond.L.Unlock()
+			return s.unjournaled(msg.event, err)
+		}
+	}
+	s.published++
+
+	// Retain the event for the subscribers to come, even if there are none yet
+	if s.history != nil {
+		s.retain(msg)
+	}
+	if len(s.subs) == 0 {
+		s.cond.L.Unlock()
+		return ErrNoSubscribers
+	}
+
+	// Add to all queues, and to a single member of each queue group
+	var evicted []*consumer[T]
+	var delivered int
+	for _, sub := range s.subs {
+		if sub.shared == nil && sub.accepts(msg.event) {
+			delivered += s.deliver(sub, msg, &evicted)
+		}
+	}
+	for _, q := range s.shared {
+		if sub := q.pick(msg.event); sub != nil {
+			delivered += s.deliver(sub, msg, &evicted)
+		}
+	}
+
+	// Wait for every consumer that received the event, they can't start processing
+	// it before the lock is released.
+	if msg.meta != nil && msg.meta.wait != nil {
+		msg.meta.wait.add(delivered)
+	}
+
+	s.expel(evicted)
+	return nil
+}
+
+// expel removes the evicted consumers and drops their queues, then releases the
+// lock. This must be called while holding the lock.
+func (s *group[T]) expel(evicted []*consumer[T]) {
+	for _, sub := range evicted {
+		s.remove(sub)
+		sub.discard(s, sub.queue)
+		sub.queue = nil
+	}
+
+	s.cond.L.Unlock()
+
+	// Notify outside of the critical section, so callbacks can safely publish
+	for _, sub := range evicted {
+		if sub.onEvict != nil {
+			sub.onEvict()
+		}
+	}
+}
+
+// deliver appends the message to the queue of the consumer, applying its overflow
+// policy, and updates the high water mark. It returns 1 if the message was queued.
+func (s *group[T]) deliver(sub *consumer[T], msg message[T], evicted *[]*consumer[T]) int {
+	if len(sub.queue) >= s.maxQueue {
+		switch sub.overflow {
+		case OverflowDropNewest:
+			sub.reject(s, msg)
+			return 0
+		case OverflowDropOldest:
+			sub.discard(s, sub.queue[:1])
+			sub.queue = sub.queue[1:]
+		case OverflowEvict:
+			sub.reject(s, msg)
+			*evicted = append(*evicted, sub)
+			return 0
 		}
 	}
 
-	// Add to all queues and update high water mark
+	if sub.maxWait > 0 && len(sub.queue) == 0 {
+		sub.since = time.Now()
+	}
+
+	sub.queue = append(sub.queue, msg)
+	if sub.overflow == OverflowBlock && len(sub.queue) > s.maxLen {
+		s.maxLen = len(sub.queue)
+	}
+	if len(sub.queue) > sub.peak {
+		sub.peak = len(sub.queue)
+		if sub.peak > s.peak {
+			s.peak = sub.peak
+		}
+	}
+	return 1
+}
+
+// pressure returns the length of the fullest blocking queue the event would be
+// appended to. For queue groups, only the least loaded member is considered.
+func (s *group[T]) pressure(ev T) (n int) {
 	for _, sub := range s.subs {
-		sub.queue = append(sub.queue, ev)
-		if len(sub.queue) > s.maxLen {
-			s.maxLen = len(sub.queue)
+		if sub.shared == nil && sub.overflow == OverflowBlock && len(sub.queue) > n && sub.accepts(ev) {
+			n = len(sub.queue)
+		}
+	}
+
+	for _, q := range s.shared {
+		if sub := q.least(ev); sub != nil && sub.overflow == OverflowBlock && len(sub.queue) > n {
+			n = len(sub.queue)
 		}
 	}
+	return
 }
 
-// Add adds a subscriber to the list
-func (s *group[T]) Add(handler func(T)) *consumer[T] {
+// Add adds a subscriber to the list, or returns an error if one of its typed
+// options does not match the type of the group.
+func (s *group[T]) Add(handler handlerFunc[T], options subscription) (*consumer[T], error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	sub := &consumer[T]{
-		queue: make([]T, 0, 64),
+		queue:      make([]message[T], 0, s.capacity),
+		id:         s.owner.nextID.Add(1),
+		label:      options.label,
+		ctx:        options.ctx,
+		handler:    handler,
+		overflow:   options.overflow,
+		onEvict:    options.onEvict,
+		keepAlive:  options.keepAlive,
+		stamp:      options.stamp,
+		workers:    options.workers,
+		maxBatch:   options.maxBatch,
+		maxWait:    options.maxWait,
+		retry:      options.retry,
+		deadLetter: options.deadLetter,
+		letters:    options.letters,
 	}
 
-	// Add the consumer to the list of active consumers
+	// Retries wait for their next attempt, unless interrupted
+	if options.retry.MaxAttempts > 1 {
+		sub.halt = make(chan struct{})
+	}
+
+	// Filter is typed, make sure it matches the type of the group
+	if options.filter != nil {
+		filter, ok := options.filter.(func(T) bool)
+		if !ok {
+			return nil, errConflict[T](s.eventType, fmt.Sprintf("%T", options.filter))
+		}
+		sub.filter = filter
+	}
+
+	// Batch handler is typed as well
+	if options.bulk != nil {
+		bulk, ok := options.bulk.(func([]T))
+		if !ok {
+			return nil, errConflict[T](s.eventType, fmt.Sprintf("%T", options.bulk))
+		}
+		sub.bulk = bulk
+	}
+
+	// Ordering key is typed as well
+	if options.key != nil {
+		key, ok := options.key.(func(T) uint64)
+		if !ok {
+			return nil, errConflict[T](s.eventType, fmt.Sprintf("%T", options.key))
+		}
+		sub.key = key
+	}
+
+	// Read the journaled history before locking, so publishers are not blocked
+	var backlog []message[T]
+	if options.replay {
+		backlog, options.position = s.backlog(options.position)
+	}
+
+	// Ordered consumers are members of the chain rather than listening on their own
 	s.cond.L.Lock()
+	if options.ordered && sub.bulk == nil {
+		runner := s.chainTo(sub, options.priority)
+		if s.sticky {
+			s.recall(sub)
+		}
+		s.cond.L.Unlock()
+		s.cond.Broadcast()
+		if runner != nil {
+			s.owner.active.Add(1)
+			go runner.Listen(s)
+		}
+		return sub, nil
+	}
+
+	// Add the consumer to the list of active consumers
 	s.subs = append(s.subs, sub)
+	if sub.stamp {
+		s.stamps++
+	}
+
+	// Within a queue group, only the member creating it receives the retained events
+	// since the members compete for the same events.
+	leader := true
+	if options.queue != "" {
+		leader = s.join(sub, options.queue)
+	}
+	switch {
+	case !leader:
+	case options.replay:
+		s.rewind(sub, backlog, options.position)
+	case s.sticky:
+		s.recall(sub)
+	}
 	s.cond.L.Unlock()
 
 	// Start listening
-	go sub.Listen(s.cond, handler)
-	return sub
+	s.owner.active.Add(1)
+	go sub.Listen(s)
+	return sub, nil
+}
+
+// Attach adds a type-erased subscriber to the list
+func (s *group[T]) Attach(handler func(context.Context, Event) error, options subscription) context.CancelFunc {
+	// Predicates and ordering keys of wildcards are given on the Event interface
+	if filter, ok := options.filter.(func(Event) bool); ok {
+		options.filter = func(ev T) bool { return filter(ev) }
+	}
+	if key, ok := options.key.(func(Event) uint64); ok {
+		options.key = func(ev T) uint64 { return key(ev) }
+	}
+
+	sub, err := s.Add(func(ctx context.Context, m *message[T]) error {
+		return handler(ctx, m.event)
+	}, options)
+	if err != nil {
+		panic(err)
+	}
+
+	return func() {
+		s.Del(sub)
+	}
 }
 
 // Del removes a subscriber from the list
 func (s *group[T]) Del(sub *consumer[T]) {
 	s.cond.L.Lock()
 	defer s.cond.L.Unlock()
+	s.remove(sub)
+}
+
+// Drop removes a subscriber from the list and discards its queued events
+func (s *group[T]) Drop(sub *consumer[T]) {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	s.remove(sub)
+	sub.discard(s, sub.queue)
+	sub.queue = nil
+}
 
-	// Search and remove the subscriber
+// Close stops all of the consumers, letting them drain their queues before exiting
+func (s *group[T]) Close() {
+	s.cond.L.Lock()
+	s.closed = true
+	for _, sub := range s.subs {
+		sub.stop = true
+	}
+	s.cond.L.Unlock()
+	s.cond.Broadcast()
+}
+
+// Abandon discards the events queued for all of the consumers and returns the
+// number of events that were discarded or are still being processed.
+func (s *group[T]) Abandon() (count int) {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	for _, sub := range s.subs {
+		sub.abort.Store(true)
+		count += len(sub.queue) + int(sub.inflight.Swap(0)) + len(sub.pending)
+		sub.interrupt(s)
+		sub.discard(s, sub.queue)
+		sub.queue = nil
+	}
+	return
+}
+
+// discard releases any publisher waiting on the discarded messages
+func discard[T Event](queue []message[T]) {
+	for _, msg := range queue {
+		msg.meta.complete(ErrDropped)
+	}
+}
+
+// remove stops the subscriber and removes it from the list, this must be called
+// while holding the group lock.
+func (s *group[T]) remove(sub *consumer[T]) {
 	sub.stop = true
+	sub.interrupt(s)
+	if sub.chained {
+		s.unchain(sub)
+		return
+	}
+
 	for i, v := range s.subs {
 		if v == sub {
 			copy(s.subs[i:], s.subs[i+1:])
 			s.subs = s.subs[:len(s.subs)-1]
+			if sub.stamp {
+				s.stamps--
+			}
+			if sub.shared != nil {
+				s.leave(sub)
+			}
 			break
 		}
 	}
@@ -303,10 +1042,13 @@ func (s *group[T]) Del(sub *consumer[T]) {
 
 // ------------------------------------- Debugging -------------------------------------
 
-var errClosed = fmt.Errorf("event dispatcher is closed")
-
 // Count returns the number of subscribers in this group
 func (s *group[T]) Count() int {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	if s.chain != nil {
+		return len(s.subs) + s.chain.size() - 1
+	}
 	return len(s.subs)
 }
 
@@ -318,11 +1060,11 @@ func (s *group[T]) String() string {
 	return typ
 }
 
-// errConflict returns a conflict message
-func errConflict[T any](eventType uint32, existing any) string {
+// errConflict returns a conflict error
+func errConflict[T any](eventType uint32, existing any) error {
 	var want T
-	return fmt.Sprintf(
-		"conflicting event type, want=<%T>, registered=<%s>, event=0x%v",
+	return fmt.Errorf(
+		"%w, want=<%T>, registered=<%s>, event=0x%v", ErrConflict,
 		want, existing, eventType,
 	)
 }
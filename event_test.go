@@ -4,6 +4,7 @@
 package event
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -101,6 +102,180 @@ func TestCloseDispatcher(t *testing.T) {
 	})
 }
 
+func TestCloseTwice(t *testing.T) {
+	d := NewDispatcher()
+	assert.NoError(t, d.Close())
+	assert.NoError(t, d.Close())
+}
+
+func TestShutdownDrain(t *testing.T) {
+	d := NewDispatcher()
+
+	var processed atomic.Int64
+	cancel := Subscribe(d, func(ev MyEvent1) {
+		time.Sleep(100 * time.Microsecond)
+		processed.Add(1)
+	})
+
+	// A cancelled subscriber must still drain its queue
+	var cancelled atomic.Int64
+	SubscribeTo(d, TypeEvent1, func(ev MyEvent1) {
+		time.Sleep(100 * time.Microsecond)
+		cancelled.Add(1)
+	})
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	cancel()
+	abandoned, err := d.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, abandoned)
+	assert.Equal(t, int64(100), processed.Load())
+	assert.Equal(t, int64(100), cancelled.Load())
+
+	// Events published after shutdown are not delivered
+	Publish(d, MyEvent1{})
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, int64(100), processed.Load())
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	d := NewDispatcher()
+	release := make(chan struct{})
+	defer close(release)
+
+	var processed atomic.Int64
+	Subscribe(d, func(ev MyEvent1) {
+		<-release
+		processed.Add(1)
+	})
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	abandoned, err := d.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Greater(t, abandoned, 0)
+	assert.LessOrEqual(t, abandoned, 100)
+}
+
+func TestShutdownBlockedPublisher(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	release := make(chan struct{})
+	defer close(release)
+
+	Subscribe(d, func(ev MyEvent1) {
+		<-release
+	})
+
+	// Publisher is blocked by backpressure, it must be released on shutdown
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			Publish(d, MyEvent1{Number: i})
+		}
+		close(published)
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	d.Shutdown(ctx)
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		assert.Fail(t, "publisher is still blocked")
+	}
+}
+
+func TestTryPublish(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), ErrNoSubscribers)
+
+	// Unsubscribed group has no subscribers either
+	Subscribe(d, func(ev MyEvent2) {})()
+	assert.ErrorIs(t, TryPublish(d, MyEvent2{}), ErrNoSubscribers)
+
+	// Conflicting types must not panic
+	SubscribeTo(d, TypeEvent1, func(ev MyEvent3) {})
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), ErrConflict)
+
+	// Queue full
+	release := make(chan struct{})
+	Subscribe(d, func(ev MyEvent2) {
+		<-release
+	})
+
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = TryPublish(d, MyEvent2{})
+	}
+	assert.ErrorIs(t, err, ErrFull)
+	close(release)
+
+	// Closed dispatcher
+	assert.NoError(t, d.Close())
+	assert.ErrorIs(t, TryPublish(d, MyEvent2{}), ErrClosed)
+}
+
+func TestClosedSubscribe(t *testing.T) {
+	d := NewDispatcher()
+	assert.NoError(t, d.Close())
+
+	defer func() {
+		assert.ErrorIs(t, recover().(error), ErrClosed)
+	}()
+	Subscribe(d, func(ev MyEvent1) {})
+}
+
+func TestConflictError(t *testing.T) {
+	d := NewDispatcher()
+	SubscribeTo(d, TypeEvent1, func(ev MyEvent2) {})
+
+	defer func() {
+		err := recover().(error)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Contains(t, err.Error(), "want=<event.MyEvent1>")
+	}()
+	Publish(d, MyEvent1{})
+}
+
+func TestTrySubscribe(t *testing.T) {
+	d := NewDispatcher()
+
+	// Subscribes like Subscribe
+	out := make(chan int, 1)
+	cancel, err := TrySubscribe(d, func(ev MyEvent1) {
+		out <- ev.Number
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, TryPublish(d, MyEvent1{Number: 1}))
+	assert.Equal(t, 1, <-out)
+	cancel()
+
+	// Conflicting types must not panic
+	_, err = TrySubscribeTo(d, TypeEvent1, func(ev MyEvent2) {})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Conflicting options must not panic either
+	_, err = TrySubscribe(d, func(ev MyEvent1) {}, WithFilter(func(ev MyEvent2) bool {
+		return true
+	}))
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Closed dispatcher
+	assert.NoError(t, d.Close())
+	_, err = TrySubscribe(d, func(ev MyEvent1) {})
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
 func TestMatrix(t *testing.T) {
 	const amount = 1000
 	for _, subs := range []int{1, 10, 100} {
@@ -276,8 +451,7 @@ func TestConcurrentHandlerRegistration(t *testing.T) {
 }
 
 func TestBackpressure(t *testing.T) {
-	d := NewDispatcher()
-	d.maxQueue = 10
+	d := NewDispatcher(WithMaxQueue(10))
 
 	var processedCount int64
 	unsub := SubscribeTo(d, uint32(0x200), func(ev MyEvent3) {
@@ -298,6 +472,84 @@ func TestBackpressure(t *testing.T) {
 	t.Logf("Events processed: %d/%d", finalProcessed, eventsToPublish)
 }
 
+func TestOverflowDropNewest(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(10))
+	defer d.Close()
+
+	release := make(chan struct{})
+	var processed, last atomic.Int64
+	defer Subscribe(d, func(ev MyEvent1) {
+		<-release
+		processed.Add(1)
+		last.Store(int64(ev.Number))
+	}, WithOverflow(OverflowDropNewest))()
+
+	// Must not block even though the subscriber is stuck
+	for i := 1; i <= 1000; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, processed.Load(), int64(20))
+	assert.Less(t, last.Load(), int64(1000))
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(10))
+	defer d.Close()
+
+	release := make(chan struct{})
+	var processed, last atomic.Int64
+	defer Subscribe(d, func(ev MyEvent1) {
+		<-release
+		processed.Add(1)
+		last.Store(int64(ev.Number))
+	}, WithOverflow(OverflowDropOldest))()
+
+	// Must not block even though the subscriber is stuck
+	for i := 1; i <= 1000; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return last.Load() == 1000
+	}, time.Second, time.Millisecond)
+	assert.LessOrEqual(t, processed.Load(), int64(20))
+}
+
+func TestOverflowEvict(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(10))
+	defer d.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var evicted atomic.Int32
+	Subscribe(d, func(ev MyEvent1) {
+		<-release
+	}, WithEviction(func() {
+		evicted.Add(1)
+	}))
+
+	// The healthy subscriber must keep receiving events
+	var received atomic.Int32
+	defer Subscribe(d, func(ev MyEvent1) {
+		received.Add(1)
+	})()
+
+	for i := 0; i < 100; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Equal(t, int32(1), evicted.Load())
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	assert.Eventually(t, func() bool {
+		return received.Load() == 100
+	}, time.Second, time.Millisecond)
+}
+
 // ------------------------------------- Test Events -------------------------------------
 
 const (
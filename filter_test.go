@@ -0,0 +1,93 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeWhere(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	var sum atomic.Int64
+	wg.Add(5)
+	defer SubscribeWhere(d, func(ev MyEvent1) bool {
+		return ev.Number%2 == 0
+	}, func(ev MyEvent1) {
+		sum.Add(int64(ev.Number))
+		wg.Done()
+	})()
+
+	for i := 1; i <= 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(2+4+6+8+10), sum.Load())
+}
+
+func TestFilterBackpressure(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(5))
+	defer d.Close()
+
+	// Stuck subscriber which only accepts odd events
+	release := make(chan struct{})
+	defer close(release)
+	SubscribeWhere(d, func(ev MyEvent1) bool {
+		return ev.Number%2 == 1
+	}, func(ev MyEvent1) {
+		<-release
+	})
+
+	// Fill up its queue, then publish lots of filtered out events
+	for TryPublish(d, MyEvent1{Number: 1}) == nil {
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			Publish(d, MyEvent1{Number: 2})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "filtered out events must not be blocked")
+	}
+}
+
+func TestFilterSync(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count atomic.Int32
+	defer SubscribeWhere(d, func(ev MyEvent1) bool {
+		return false
+	}, func(ev MyEvent1) {
+		count.Add(1)
+	})()
+
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+	assert.Equal(t, int32(0), count.Load())
+}
+
+func TestFilterConflict(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	assert.Panics(t, func() {
+		Subscribe(d, func(ev MyEvent1) {}, WithFilter(func(ev MyEvent2) bool {
+			return true
+		}))
+	})
+}
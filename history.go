@@ -0,0 +1,200 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// SubscribeFrom subscribes to an event, first replaying the retained events of the
+// type whose sequence number is at least the position, then switching to the live
+// events without any gap or duplicate. Events are retained by the dispatcher with
+// the WithHistory option and, if a journal is attached, older events are read back
+// from the journal. The type of the event will be automatically inferred from the
+// provided type.
+func SubscribeFrom[T Event](broker *Dispatcher, position uint64, handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return Subscribe(broker, handler, append(opts, WithReplay(position))...)
+}
+
+// WithReplay replays the retained events whose sequence number is at least the
+// position to the subscriber, before any live event. A position of zero replays
+// every retained event. The position typically is the sequence number following
+// the one of the last envelope processed, see SubscribeEnvelope. Within a queue
+// group, only the member creating the queue group replays the retained events. It
+// can not be combined with WithPriority, as ordered subscribers share their queue.
+func WithReplay(position uint64) SubscribeOption {
+	return func(s *subscription) {
+		s.replay = true
+		s.position = position
+	}
+}
+
+// retainGroup returns the group of the event type if its events are retained, so
+// that they are retained even before anybody subscribed, or nil otherwise.
+func retainGroup[T Event](broker *Dispatcher, eventType uint32) *group[T] {
+	if !broker.config.retained || broker.config.configFor(eventType).retains() == 0 {
+		return nil
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.isClosed() {
+		return nil
+	}
+
+	return groupFor[T](broker, eventType)
+}
+
+// ------------------------------------- History -------------------------------------
+
+// history represents a bounded ring of the most recent messages of a group
+type history[T Event] struct {
+	ring  []message[T] // Retained messages, grown up to the limit
+	head  int          // Index of the oldest message, once the ring is full
+	limit int          // Maximum number of retained messages
+}
+
+// newHistory creates a new history retaining up to the specified number of
+// messages, or returns nil if nothing is retained.
+func newHistory[T Event](limit int) *history[T] {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &history[T]{limit: limit}
+}
+
+// push retains the message, overwriting the oldest one if the ring is full
+func (h *history[T]) push(msg message[T]) {
+	if len(h.ring) < h.limit {
+		h.ring = append(h.ring, msg)
+		return
+	}
+
+	h.ring[h.head] = msg
+	h.head = (h.head + 1) % h.limit
+}
+
+// since appends the retained messages whose sequence number is at least the
+// specified one, from the oldest to the most recent.
+func (h *history[T]) since(from uint64, out []message[T]) []message[T] {
+	for i := range h.ring {
+		if msg := h.ring[(h.head+i)%len(h.ring)]; msg.seq >= from {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// last returns the most recent retained message, if any
+func (h *history[T]) last() (message[T], bool) {
+	if len(h.ring) == 0 {
+		return message[T]{}, false
+	}
+
+	return h.ring[(h.head+len(h.ring)-1)%len(h.ring)], true
+}
+
+// retain retains the message in the history of the group, this must be called
+// while holding the lock.
+func (s *group[T]) retain(msg message[T]) {
+	s.history.push(msg.detach())
+}
+
+// detach returns a copy of the message without the metadata that only matters to
+// the publisher, such as its context or its waiter, so it can be delivered again.
+func (m message[T]) detach() message[T] {
+	if meta := m.meta; meta != nil {
+		m.meta = nil
+		if meta.source != "" || meta.headers != nil {
+			m.meta = &metadata{source: meta.source, headers: meta.headers}
+		}
+	}
+	return m
+}
+
+// oldest returns the sequence number of the oldest retained message, or the next
+// sequence number if there is none. This must be called while holding the lock.
+func (s *group[T]) oldest() uint64 {
+	if s.history != nil && len(s.history.ring) > 0 {
+		return s.history.ring[s.history.head].seq
+	}
+	return s.owner.seq.Load() + 1
+}
+
+// backlog reads the journaled events of the group starting at the position that
+// are no longer retained in memory, without holding the lock so publishers are not
+// blocked while the journal is read. It returns the events read along with the
+// position to continue from.
+func (s *group[T]) backlog(from uint64) ([]message[T], uint64) {
+	journal := s.owner.config.journal
+	if journal == nil {
+		return nil, from
+	}
+
+	s.cond.L.Lock()
+	until := s.oldest()
+	s.cond.L.Unlock()
+	if from >= until {
+		return nil, from
+	}
+
+	return readJournal[T](journal, s.eventType, from, until, nil), until
+}
+
+// rewind queues the events starting at the position for the consumer, after the
+// backlog read from the journal. Events published while the backlog was read are
+// read from the journal again if they are no longer retained in memory. This must
+// be called while holding the lock, so that no event is published in between.
+func (s *group[T]) rewind(sub *consumer[T], backlog []message[T], from uint64) {
+	if journal := s.owner.config.journal; journal != nil {
+		if until := s.oldest(); from < until {
+			backlog = readJournal[T](journal, s.eventType, from, until, backlog)
+		}
+	}
+
+	if s.history != nil {
+		backlog = s.history.since(from, backlog)
+	}
+	s.seed(sub, backlog)
+}
+
+// recall queues the most recent event for the consumer, if any was retained. This
+// must be called while holding the lock.
+func (s *group[T]) recall(sub *consumer[T]) {
+	msg, ok := s.history.last()
+	switch {
+	case !ok:
+	case !sub.chained:
+		s.seed(sub, []message[T]{msg})
+
+	// Ordered consumers share the queue of the runner, so the event is queued for
+	// the new member only. If the runner has events queued, the most recent one is
+	// among them and the new member receives it along with the others.
+	case len(s.chain.runner.queue) == 0 && sub.accepts(msg.event):
+		meta := &metadata{target: sub}
+		if msg.meta != nil {
+			*meta = *msg.meta
+			meta.target = sub
+		}
+
+		msg.meta = meta
+		s.chain.runner.queue = append(s.chain.runner.queue, msg)
+	}
+}
+
+// seed queues the retained messages accepted by a new consumer, ahead of the live
+// events. This must be called while holding the lock.
+func (s *group[T]) seed(sub *consumer[T], messages []message[T]) {
+	for _, msg := range messages {
+		if sub.accepts(msg.event) {
+			sub.queue = append(sub.queue, msg)
+		}
+	}
+
+	if sub.maxWait > 0 && len(sub.queue) > 0 {
+		sub.since = time.Now()
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeFrom(t *testing.T) {
+	d := NewDispatcher(WithHistory(10))
+	defer d.Close()
+
+	// Events are retained even though nobody subscribed yet
+	for i := 1; i <= 5; i++ {
+		assert.ErrorIs(t, TryPublish(d, MyEvent1{Number: i}), ErrNoSubscribers)
+	}
+
+	out := make(chan int, 10)
+	defer SubscribeFrom(d, 3, func(ev MyEvent1) {
+		out <- ev.Number
+	})()
+
+	Publish(d, MyEvent1{Number: 6})
+	for i := 3; i <= 6; i++ {
+		assert.Equal(t, i, <-out)
+	}
+}
+
+func TestSubscribeFromBounded(t *testing.T) {
+	d := NewDispatcher(WithHistory(3))
+	defer d.Close()
+	for i := 1; i <= 5; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	// Only the most recent events are retained, with their metadata
+	out := make(chan Envelope[MyEvent1], 10)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {
+		out <- env
+	}, WithReplay(0))()
+
+	for i := 3; i <= 5; i++ {
+		env := <-out
+		assert.Equal(t, i, env.Event.Number)
+		assert.Equal(t, uint64(i), env.Seq)
+		assert.False(t, env.Time.IsZero())
+	}
+}
+
+func TestSubscribeFromPerType(t *testing.T) {
+	d := NewDispatcher(WithType(TypeEvent1, WithHistory(1)))
+	defer d.Close()
+
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{Number: 1}), ErrNoSubscribers)
+	assert.ErrorIs(t, TryPublish(d, MyEvent2{Text: "a"}), ErrNoSubscribers)
+	assert.NotNil(t, d.findGroup(TypeEvent1))
+	assert.Nil(t, d.findGroup(TypeEvent2))
+
+	// Without a position, nothing is replayed
+	out := make(chan int, 10)
+	defer Subscribe(d, func(ev MyEvent1) {
+		out <- ev.Number
+	})()
+	Publish(d, MyEvent1{Number: 2})
+	assert.Equal(t, 2, <-out)
+}
+
+func TestSubscribeFromLive(t *testing.T) {
+	d := NewDispatcher(WithHistory(1000))
+	defer d.Close()
+
+	// Subscribe while the events are being published
+	const count = 500
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= count; i++ {
+			Publish(d, MyEvent1{Number: i})
+		}
+	}()
+
+	out := make(chan int, count)
+	defer SubscribeFrom(d, 0, func(ev MyEvent1) {
+		out <- ev.Number
+	})()
+	wg.Wait()
+
+	// Every event is received once and in order, retained or live
+	for i := 1; i <= count; i++ {
+		assert.Equal(t, i, <-out)
+	}
+}
+
+func TestSubscribeFromJournal(t *testing.T) {
+	journal := openJournal(t, t.TempDir(), WithSegmentSize(100))
+	defer journal.Close()
+
+	d := NewDispatcher(WithJournal(journal), WithHistory(2))
+	defer d.Close()
+	defer Subscribe(d, func(ev MyEvent2) {})()
+	for i := 1; i <= 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+		Publish(d, MyEvent2{Text: "skipped"})
+	}
+
+	// Older events are read back from the journal, the others from memory
+	out := make(chan Envelope[MyEvent1], 20)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {
+		out <- env
+	}, WithReplay(5))()
+
+	Publish(d, MyEvent1{Number: 11})
+	for i := 3; i <= 11; i++ {
+		env := <-out
+		assert.Equal(t, i, env.Event.Number)
+		assert.Equal(t, uint64(2*i-1), env.Seq)
+	}
+}
+
+func TestSubscribeFromQueue(t *testing.T) {
+	d := NewDispatcher(WithHistory(10))
+	defer d.Close()
+	for i := 1; i <= 3; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	// The retained events are replayed once for the whole queue group
+	var count atomic.Int32
+	for i := 0; i < 3; i++ {
+		defer SubscribeQueue(d, "workers", func(ev MyEvent1) {
+			count.Add(1)
+		}, WithReplay(0))()
+	}
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 4}))
+	assert.Eventually(t, func() bool {
+		return count.Load() == 4
+	}, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(4), count.Load())
+}
+
+func TestSubscribeFromPriority(t *testing.T) {
+	d := NewDispatcher(WithHistory(10))
+	defer d.Close()
+
+	// Ordered subscribers can not replay the retained events
+	_, err := TrySubscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithReplay(0))
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.Panics(t, func() {
+		SubscribeFrom(d, 0, func(ev MyEvent1) {}, WithPriority(1))
+	})
+	assert.Panics(t, func() {
+		SubscribeAll(d, func(ev Event) {}, WithPriority(1), WithReplay(0))
+	})
+}
+
+func TestRetain(t *testing.T) {
+	d := NewDispatcher(WithType(TypeEvent1, WithRetain()))
+	defer d.Close()
+	Publish(d, MyEvent1{Number: 1})
+	Publish(d, MyEvent1{Number: 2})
+
+	// The most recent event is delivered first, then the live ones
+	out1 := make(chan int, 10)
+	defer Subscribe(d, func(ev MyEvent1) {
+		out1 <- ev.Number
+	})()
+	assert.Equal(t, 2, <-out1)
+
+	Publish(d, MyEvent1{Number: 3})
+	assert.Equal(t, 3, <-out1)
+
+	// Every new subscriber receives the most recent event, unless filtered out
+	out2 := make(chan int, 10)
+	defer Subscribe(d, func(ev MyEvent1) {
+		out2 <- ev.Number
+	})()
+	out3 := make(chan int, 10)
+	defer SubscribeWhere(d, func(ev MyEvent1) bool {
+		return ev.Number%2 == 0
+	}, func(ev MyEvent1) {
+		out3 <- ev.Number
+	})()
+
+	Publish(d, MyEvent1{Number: 4})
+	assert.Equal(t, 3, <-out2)
+	assert.Equal(t, 4, <-out2)
+	assert.Equal(t, 4, <-out3)
+}
+
+func TestRetainOrdered(t *testing.T) {
+	d := NewDispatcher(WithType(TypeEvent1, WithRetain()))
+	defer d.Close()
+	Publish(d, MyEvent1{Number: 1})
+
+	// Every new member of the chain receives the most recent event, only once
+	out1 := make(chan int, 10)
+	defer Subscribe(d, func(ev MyEvent1) {
+		out1 <- ev.Number
+	}, WithPriority(1))()
+	assert.Equal(t, 1, <-out1)
+
+	out2 := make(chan int, 10)
+	defer Subscribe(d, func(ev MyEvent1) {
+		out2 <- ev.Number
+	}, WithPriority(2))()
+	assert.Equal(t, 1, <-out2)
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.Equal(t, 2, <-out1)
+	assert.Equal(t, 2, <-out2)
+	assert.Empty(t, out1)
+	assert.Empty(t, out2)
+}
+
+func TestRetainQueue(t *testing.T) {
+	d := NewDispatcher(WithType(TypeEvent1, WithRetain()))
+	defer d.Close()
+	Publish(d, MyEvent1{Number: 1})
+
+	// The most recent event is delivered once for the whole queue group
+	out := make(chan int, 10)
+	for i := 0; i < 3; i++ {
+		defer SubscribeQueue(d, "workers", func(ev MyEvent1) {
+			out <- ev.Number
+		})()
+	}
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.ElementsMatch(t, []int{1, 2}, []int{<-out, <-out})
+	assert.NoError(t, d.Close())
+	assert.Empty(t, out)
+}
+
+func TestHistoryRing(t *testing.T) {
+	h := newHistory[MyEvent1](3)
+	assert.Nil(t, newHistory[MyEvent1](0))
+
+	for i := 1; i <= 7; i++ {
+		h.push(message[MyEvent1]{event: MyEvent1{Number: i}, seq: uint64(i)})
+	}
+
+	var numbers []int
+	for _, msg := range h.since(6, nil) {
+		numbers = append(numbers, msg.event.Number)
+	}
+	assert.Equal(t, []int{6, 7}, numbers)
+	assert.Len(t, h.since(0, nil), 3)
+
+	last, ok := h.last()
+	assert.True(t, ok)
+	assert.Equal(t, 7, last.event.Number)
+
+	_, ok = newHistory[MyEvent1](1).last()
+	assert.False(t, ok)
+}
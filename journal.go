@@ -0,0 +1,582 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCorrupt is returned when a segment of a journal contains an invalid record
+var ErrCorrupt = errors.New("event: journal record is corrupt")
+
+// SyncPolicy represents when the journal flushes its writes to stable storage
+type SyncPolicy uint8
+
+// Various sync policies
+const (
+	SyncNever    SyncPolicy = iota // Leave it to the operating system
+	SyncAlways                     // Sync after every appended event
+	SyncInterval                   // Sync periodically, see WithSyncInterval
+)
+
+// JournalOption represents a journal configuration option.
+type JournalOption func(*journalConfig)
+
+// journalConfig represents the configuration of a journal
+type journalConfig struct {
+	segmentSize int64         // Size after which a new segment is started
+	sync        SyncPolicy    // Sync policy
+	interval    time.Duration // Sync interval, for the interval policy
+}
+
+// WithSegmentSize sets the size in bytes after which the journal starts a new
+// segment file. Smaller segments can be truncated sooner.
+func WithSegmentSize(size int64) JournalOption {
+	return func(c *journalConfig) {
+		if size > 0 {
+			c.segmentSize = size
+		}
+	}
+}
+
+// WithSync sets the sync policy of the journal. By default, the journal leaves it
+// to the operating system to flush its writes, which survives a crash of the
+// process but not necessarily of the machine.
+func WithSync(policy SyncPolicy) JournalOption {
+	return func(c *journalConfig) {
+		c.sync = policy
+	}
+}
+
+// WithSyncInterval syncs the journal periodically, bounding the window of events
+// that can be lost on a crash of the machine without syncing every event.
+func WithSyncInterval(interval time.Duration) JournalOption {
+	return func(c *journalConfig) {
+		if interval > 0 {
+			c.sync = SyncInterval
+			c.interval = interval
+		}
+	}
+}
+
+// Journal represents a durable, segmented write-ahead log of published events. A
+// journal is attached to a dispatcher with the WithJournal option, and only the
+// event types with a codec registered with RegisterCodec are journaled.
+type Journal struct {
+	mu       sync.Mutex
+	dir      string                 // Directory of the segments
+	config   journalConfig          // Configuration of the journal
+	codecs   atomic.Pointer[codecs] // Codecs per event type (immutable)
+	segments []uint64               // First sequence of every segment
+	file     *os.File               // Current segment
+	size     int64                  // Size of the current segment
+	last     uint64                 // Last sequence appended
+	buffer   []byte                 // Reusable buffer for the records
+	dirty    bool                   // Whether there are unsynced writes
+	closed   bool                   // Whether the journal is closed
+	done     chan struct{}          // Stops the background sync
+}
+
+// codecs represents the codecs of a journal, per event type
+type codecs map[uint32]*codec
+
+// codec represents a type-erased codec of an event type
+type codec struct {
+	encode func(Event) ([]byte, error)
+	decode func([]byte) (Event, error)
+	replay func(*Dispatcher, []byte, uint64, int64) error
+}
+
+// OpenJournal opens the journal in the directory, creating it if necessary. If the
+// journal was not closed properly, the incomplete record at its end is discarded.
+func OpenJournal(dir string, opts ...JournalOption) (*Journal, error) {
+	j := &Journal{
+		dir: dir,
+		config: journalConfig{
+			segmentSize: 64 << 20,
+			interval:    time.Second,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&j.config)
+	}
+
+	j.codecs.Store(&codecs{})
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Load the existing segments and recover the last one
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	if j.config.sync == SyncInterval {
+		j.done = make(chan struct{})
+		go j.syncEvery(j.config.interval)
+	}
+	return j, nil
+}
+
+// RegisterCodec registers the encoder and the decoder of an event type on the
+// journal. Events of the type are journaled once they are published, and decoded
+// when replayed. The type of the event will be automatically inferred from the
+// provided type.
+func RegisterCodec[T Event](journal *Journal, encode func(T) ([]byte, error), decode func([]byte) (T, error)) {
+	var event T
+	c := &codec{
+		encode: func(ev Event) ([]byte, error) {
+			return encode(ev.(T))
+		},
+		decode: func(data []byte) (Event, error) {
+			return decode(data)
+		},
+		replay: func(d *Dispatcher, data []byte, seq uint64, stamp int64) error {
+			ev, err := decode(data)
+			if err != nil {
+				return err
+			}
+
+			// Replayed events keep their sequence, and are not journaled again
+			if group := groupTo[T](d, ev.Type()); group != nil {
+				if err := group.Broadcast(message[T]{event: ev, seq: seq, time: stamp}, true); err != nil && err != ErrNoSubscribers {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	// Copy-on-write, so publishers never need a lock to find the codec
+	next := make(codecs, len(*journal.codecs.Load())+1)
+	for k, v := range *journal.codecs.Load() {
+		next[k] = v
+	}
+	next[event.Type()] = c
+	journal.codecs.Store(&next)
+}
+
+// Last returns the sequence number of the last event appended to the journal
+func (j *Journal) Last() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last
+}
+
+// Replay re-dispatches the journaled events whose sequence number is at least the
+// specified one, in the order they were published, and returns the number of
+// events replayed. Replayed events keep their original sequence number and are
+// not journaled again. Events of a type without a codec are skipped.
+func (j *Journal) Replay(d *Dispatcher, from uint64) (count int, err error) {
+	err = j.scan(from, func(eventType uint32, seq uint64, stamp int64, data []byte) error {
+		c, ok := (*j.codecs.Load())[eventType]
+		if !ok {
+			return nil
+		}
+
+		count++
+		return c.replay(d, data, seq, stamp)
+	})
+	return
+}
+
+// readJournal decodes the journaled events of the type whose sequence number is
+// within the range, appending them to the messages. Reading stops at the first
+// record that can not be read or decoded, as the history is only best-effort.
+func readJournal[T Event](j *Journal, eventType uint32, from, until uint64, out []message[T]) []message[T] {
+	c, ok := (*j.codecs.Load())[eventType]
+	if !ok {
+		return out
+	}
+
+	j.scan(from, func(typ uint32, seq uint64, stamp int64, data []byte) error {
+		if typ != eventType || seq >= until {
+			return nil
+		}
+
+		ev, err := c.decode(data)
+		if err != nil {
+			return err
+		}
+
+		if ev, ok := ev.(T); ok {
+			out = append(out, message[T]{event: ev, seq: seq, time: stamp})
+		}
+		return nil
+	})
+	return out
+}
+
+// Truncate removes the segments only containing events with a sequence number
+// lower than the specified one, typically once they have all been processed.
+func (j *Journal) Truncate(before uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// A segment can be removed once the next one starts at or before the sequence
+	for len(j.segments) > 1 && j.segments[1] <= before {
+		if err := os.Remove(j.path(j.segments[0])); err != nil {
+			return err
+		}
+		j.segments = j.segments[1:]
+	}
+	return nil
+}
+
+// Close syncs and closes the journal. The dispatcher the journal is attached to
+// must be closed first.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.closed = true
+	if j.done != nil {
+		close(j.done)
+		j.done = nil
+	}
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Sync()
+	if cerr := j.file.Close(); err == nil {
+		err = cerr
+	}
+	j.file = nil
+	return err
+}
+
+// journalGroup returns the group of the event type if its events are journaled, so
+// that they are journaled even before anybody subscribed, or nil otherwise.
+func journalGroup[T Event](broker *Dispatcher, eventType uint32) *group[T] {
+	journal := broker.config.journal
+	if journal == nil {
+		return nil
+	}
+	if _, ok := (*journal.codecs.Load())[eventType]; !ok {
+		return nil
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.isClosed() {
+		return nil
+	}
+
+	return groupFor[T](broker, eventType)
+}
+
+// journal appends the event to the journal of the dispatcher, assigning its
+// sequence number. This must be called while holding the lock of the group.
+func (s *group[T]) journal(msg *message[T]) (err error) {
+	if msg.time == 0 {
+		msg.time = time.Now().UnixNano()
+	}
+
+	msg.seq, err = s.owner.config.journal.appendNext(s.eventType, &s.owner.seq, msg.time, msg.meta.record)
+	msg.meta.record = nil
+	return
+}
+
+// unjournaled reports an event that could not be journaled, and was therefore not
+// delivered, to the error handler of the dispatcher and returns the error.
+func (s *group[T]) unjournaled(ev T, err error) error {
+	if onError := s.owner.config.onError; onError != nil {
+		onError(&HandlerError{
+			Type:  s.eventType,
+			Event: ev,
+			Err:   err,
+		})
+	}
+	return err
+}
+
+// encode encodes the event if its type has a codec, returning nil otherwise
+func (j *Journal) encode(eventType uint32, ev Event) ([]byte, error) {
+	if c, ok := (*j.codecs.Load())[eventType]; ok {
+		return c.encode(ev)
+	}
+	return nil, nil
+}
+
+// ------------------------------------- Segments -------------------------------------
+
+// Every record starts with a header, followed by the encoded event:
+//
+//	size uint32 | crc uint32 | type uint32 | seq uint64 | time int64 | data
+const headerSize = 28
+
+// maxRecordSize is the maximum size of an encoded event, larger sizes can only be
+// found in a corrupt record.
+const maxRecordSize = 1 << 30
+
+// crcTable is the table used for the checksums of the records
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendNext assigns the next sequence number of the counter to a record and
+// appends it. The sequence number is assigned while holding the lock, so that the
+// records are ordered by sequence even if several event types are published at
+// the same time.
+func (j *Journal) appendNext(eventType uint32, counter *atomic.Uint64, stamp int64, data []byte) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return 0, os.ErrClosed
+	}
+
+	seq := counter.Add(1)
+	return seq, j.write(eventType, seq, stamp, data)
+}
+
+// write writes a record to the current segment, starting a new one if needed.
+// This must be called while holding the lock.
+func (j *Journal) write(eventType uint32, seq uint64, stamp int64, data []byte) error {
+	if j.closed {
+		return os.ErrClosed
+	}
+
+	// Start a new segment if the current one is full
+	if j.file == nil || j.size >= j.config.segmentSize {
+		if err := j.rotate(seq); err != nil {
+			return err
+		}
+	}
+
+	// Encode the record in a single buffer, so it is written at once
+	record := append(j.buffer[:0], make([]byte, headerSize)...)
+	binary.LittleEndian.PutUint32(record[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[8:], eventType)
+	binary.LittleEndian.PutUint64(record[12:], seq)
+	binary.LittleEndian.PutUint64(record[20:], uint64(stamp))
+	record = append(record, data...)
+	binary.LittleEndian.PutUint32(record[4:], crc32.Checksum(record[8:], crcTable))
+	j.buffer = record
+
+	if _, err := j.file.Write(record); err != nil {
+		return err
+	}
+
+	j.size += int64(len(record))
+	if seq > j.last {
+		j.last = seq
+	}
+	j.dirty = true
+	if j.config.sync == SyncAlways {
+		return j.flush()
+	}
+	return nil
+}
+
+// rotate closes the current segment and starts a new one, named after the first
+// sequence number it contains. This must be called while holding the lock.
+func (j *Journal) rotate(seq uint64) error {
+	if j.file != nil {
+		if err := j.flush(); err != nil {
+			return err
+		}
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+		j.file = nil
+	}
+
+	file, err := os.OpenFile(j.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	j.file = file
+	j.size = 0
+	j.segments = append(j.segments, seq)
+	return nil
+}
+
+// flush syncs the current segment if it has unsynced writes
+func (j *Journal) flush() error {
+	if j.file == nil || !j.dirty {
+		return nil
+	}
+
+	j.dirty = false
+	return j.file.Sync()
+}
+
+// syncEvery periodically syncs the current segment, until the journal is closed
+func (j *Journal) syncEvery(interval time.Duration) {
+	done := j.done
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			j.mu.Lock()
+			j.flush()
+			j.mu.Unlock()
+		}
+	}
+}
+
+// path returns the path of the segment starting at the sequence number
+func (j *Journal) path(seq uint64) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%020d.wal", seq))
+}
+
+// load lists the existing segments and recovers the last one, discarding any
+// incomplete record at its end so that new records can be appended to it.
+func (j *Journal) load() error {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".wal") {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".wal"), 10, 64)
+		if err != nil {
+			continue
+		}
+		j.segments = append(j.segments, seq)
+	}
+
+	sort.Slice(j.segments, func(i, k int) bool {
+		return j.segments[i] < j.segments[k]
+	})
+
+	if len(j.segments) == 0 {
+		return nil
+	}
+
+	// Find the end of the last valid record of the last segment
+	first := j.segments[len(j.segments)-1]
+	file, err := os.OpenFile(j.path(first), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	size, last, err := readSegment(file, 0, nil)
+	if err != nil && err != ErrCorrupt {
+		file.Close()
+		return err
+	}
+
+	// Discard the incomplete record, if any
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return err
+	}
+
+	j.file = file
+	j.size = size
+	if last > j.last {
+		j.last = last
+	}
+	if j.last == 0 {
+		j.last = first - 1
+	}
+	return nil
+}
+
+// scan reads the records of every segment, in order, starting at the sequence
+func (j *Journal) scan(from uint64, fn func(uint32, uint64, int64, []byte) error) error {
+	j.mu.Lock()
+	segments := append([]uint64(nil), j.segments...)
+	j.mu.Unlock()
+
+	for i, first := range segments {
+		if i+1 < len(segments) && segments[i+1] <= from {
+			continue // Every record of the segment is before the sequence
+		}
+
+		file, err := os.Open(j.path(first))
+		if err != nil {
+			return err
+		}
+
+		// A corrupt record can only be the incomplete end of the last segment
+		_, _, err = readSegment(file, from, fn)
+		file.Close()
+		switch {
+		case err == ErrCorrupt && i == len(segments)-1:
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+// readSegment reads the records of a segment, calling the function for the records
+// starting at the sequence, if any. It returns the size of the valid records and
+// the sequence of the last one, along with ErrCorrupt if an invalid or incomplete
+// record was found.
+func readSegment(file *os.File, from uint64, fn func(uint32, uint64, int64, []byte) error) (size int64, last uint64, err error) {
+	reader := bufio.NewReader(file)
+	header := make([]byte, headerSize)
+	var data []byte
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return size, last, nil
+			}
+			return size, last, ErrCorrupt
+		}
+
+		// Read the encoded event, reusing the buffer
+		n := int(binary.LittleEndian.Uint32(header[0:]))
+		if n > maxRecordSize {
+			return size, last, ErrCorrupt
+		}
+
+		if cap(data) < n {
+			data = make([]byte, n)
+		}
+		data = data[:n]
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return size, last, ErrCorrupt
+		}
+
+		// Validate the checksum of the record
+		crc := crc32.Update(crc32.Checksum(header[8:], crcTable), crcTable, data)
+		if crc != binary.LittleEndian.Uint32(header[4:]) {
+			return size, last, ErrCorrupt
+		}
+
+		eventType := binary.LittleEndian.Uint32(header[8:])
+		seq := binary.LittleEndian.Uint64(header[12:])
+		stamp := int64(binary.LittleEndian.Uint64(header[20:]))
+		// Pass a copy of the data, as the buffer is reused for the next record
+		if fn != nil && seq >= from {
+			if err := fn(eventType, seq, stamp, append([]byte(nil), data...)); err != nil {
+				return size, last, err
+			}
+		}
+
+		size += int64(headerSize + n)
+		if seq > last {
+			last = seq
+		}
+	}
+}
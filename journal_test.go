@@ -0,0 +1,317 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	// Publish a few events, only the first type has a codec
+	journal := openJournal(t, dir)
+	d := NewDispatcher(WithJournal(journal))
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	defer Subscribe(d, func(ev MyEvent2) {})()
+	for i := 1; i <= 10; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+		assert.NoError(t, PublishSync(d, MyEvent2{Text: "skipped"}))
+	}
+
+	d.Close()
+	assert.NoError(t, journal.Close())
+
+	// Reopen the journal, as if the process restarted
+	// Events that are not journaled are not sequenced either
+	journal = openJournal(t, dir)
+	defer journal.Close()
+	assert.Equal(t, uint64(10), journal.Last())
+
+	d = NewDispatcher(WithJournal(journal))
+	defer d.Close()
+
+	out := make(chan Envelope[MyEvent1], 20)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {
+		out <- env
+	})()
+
+	// Replayed events keep their sequence and are not journaled again
+	n, err := journal.Replay(d, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+	for i := 1; i <= 10; i++ {
+		env := <-out
+		assert.Equal(t, MyEvent1{Number: i}, env.Event)
+		assert.Equal(t, uint64(i), env.Seq)
+		assert.False(t, env.Time.IsZero())
+	}
+	assert.Equal(t, uint64(10), journal.Last())
+
+	// New events continue the sequence of the journal
+	Publish(d, MyEvent1{Number: 11})
+	assert.Equal(t, uint64(11), (<-out).Seq)
+	assert.Equal(t, uint64(11), journal.Last())
+}
+
+func TestJournalNoSubscribers(t *testing.T) {
+	journal := openJournal(t, t.TempDir())
+	defer journal.Close()
+	d := NewDispatcher(WithJournal(journal))
+	defer d.Close()
+
+	// Events are journaled even if nobody subscribed to their type yet
+	Publish(d, MyEvent1{Number: 1})
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{Number: 2}), ErrNoSubscribers)
+	assert.ErrorIs(t, TryPublish(d, MyEvent2{Text: "skipped"}), ErrNoSubscribers)
+	assert.Equal(t, uint64(2), journal.Last())
+
+	// They can be replayed to the subscribers to come
+	out := make(chan MyEvent1, 10)
+	defer SubscribeFrom(d, 0, func(ev MyEvent1) {
+		out <- ev
+	})()
+	assert.Equal(t, MyEvent1{Number: 1}, <-out)
+	assert.Equal(t, MyEvent1{Number: 2}, <-out)
+}
+
+func TestJournalSegments(t *testing.T) {
+	dir := t.TempDir()
+	journal := openJournal(t, dir, WithSegmentSize(100))
+	defer journal.Close()
+
+	d := NewDispatcher(WithJournal(journal))
+	defer d.Close()
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	for i := 1; i <= 20; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	assert.Greater(t, len(segments), 2)
+
+	// Replay from a position, skipping the earlier segments
+	var replayed []int
+	defer Subscribe(d, func(ev MyEvent1) {
+		replayed = append(replayed, ev.Number)
+	})()
+
+	n, err := journal.Replay(d, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 21}))
+	assert.Equal(t, []int{15, 16, 17, 18, 19, 20, 21}, replayed)
+
+	// Truncate the segments that were entirely processed
+	assert.NoError(t, journal.Truncate(15))
+	remaining, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	assert.Less(t, len(remaining), len(segments))
+
+	other := NewDispatcher()
+	defer other.Close()
+	n, err = journal.Replay(other, 0)
+	assert.NoError(t, err)
+	assert.Less(t, n, 21)
+	assert.GreaterOrEqual(t, n, 7)
+}
+
+func TestJournalOrder(t *testing.T) {
+	journal := openJournal(t, t.TempDir(), WithSegmentSize(1000))
+	defer journal.Close()
+	RegisterCodec(journal, func(ev MyEvent2) ([]byte, error) {
+		return json.Marshal(ev)
+	}, func(b []byte) (ev MyEvent2, err error) {
+		err = json.Unmarshal(b, &ev)
+		return
+	})
+
+	d := NewDispatcher(WithJournal(journal))
+	defer d.Close()
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	defer Subscribe(d, func(ev MyEvent2) {})()
+
+	// Publish both event types concurrently
+	const events = 500
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < events; i++ {
+			Publish(d, MyEvent1{Number: i})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < events; i++ {
+			Publish(d, MyEvent2{Text: "hello"})
+		}
+	}()
+	wg.Wait()
+
+	// Records must be appended in the order of their sequence
+	var seqs []uint64
+	assert.NoError(t, journal.scan(0, func(_ uint32, seq uint64, _ int64, _ []byte) error {
+		seqs = append(seqs, seq)
+		return nil
+	}))
+	assert.Len(t, seqs, 2*events)
+	assert.IsIncreasing(t, seqs)
+	assert.Equal(t, uint64(2*events), journal.Last())
+}
+
+func TestJournalCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	journal := openJournal(t, dir)
+	d := NewDispatcher(WithJournal(journal))
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+	}
+	d.Close()
+	assert.NoError(t, journal.Close())
+
+	// Simulate a crash in the middle of a write
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	file.Write([]byte{10, 0, 0, 0, 1, 2, 3})
+	file.Close()
+
+	// The incomplete record is discarded and new records are appended after it
+	journal = openJournal(t, dir)
+	defer journal.Close()
+	assert.Equal(t, uint64(3), journal.Last())
+
+	d = NewDispatcher(WithJournal(journal))
+	defer d.Close()
+
+	var received []int
+	defer Subscribe(d, func(ev MyEvent1) {
+		received = append(received, ev.Number)
+	})()
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 4}))
+
+	n, err := journal.Replay(d, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 5}))
+	assert.Equal(t, []int{4, 1, 2, 3, 4, 5}, received)
+}
+
+func TestJournalSync(t *testing.T) {
+	for _, opt := range []JournalOption{
+		WithSync(SyncAlways),
+		WithSyncInterval(time.Millisecond),
+	} {
+		journal := openJournal(t, t.TempDir(), opt)
+		d := NewDispatcher(WithJournal(journal))
+		defer Subscribe(d, func(ev MyEvent1) {})()
+
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+		time.Sleep(5 * time.Millisecond)
+		d.Close()
+		assert.NoError(t, journal.Close())
+
+		// Once closed, the journal no longer accepts events
+		_, err := journal.appendNext(TypeEvent1, new(atomic.Uint64), 0, nil)
+		assert.ErrorIs(t, err, os.ErrClosed)
+	}
+}
+
+func TestJournalEncodeError(t *testing.T) {
+	journal, err := OpenJournal(t.TempDir())
+	assert.NoError(t, err)
+	defer journal.Close()
+
+	invalid := errors.New("invalid")
+	RegisterCodec(journal, func(ev MyEvent1) ([]byte, error) {
+		return nil, invalid
+	}, func(b []byte) (MyEvent1, error) {
+		return MyEvent1{}, nil
+	})
+
+	var failures []*HandlerError
+	d := NewDispatcher(WithJournal(journal), WithOnError(func(err *HandlerError) {
+		failures = append(failures, err)
+	}))
+	defer d.Close()
+
+	// Events that can't be journaled are not delivered, but reported
+	var count int
+	defer Subscribe(d, func(ev MyEvent1) { count++ })()
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), invalid)
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{}), invalid)
+	Publish(d, MyEvent1{Number: 1})
+	assert.Zero(t, count)
+
+	assert.Len(t, failures, 3)
+	assert.Equal(t, MyEvent1{Number: 1}, failures[2].Event)
+	assert.Zero(t, failures[2].Subscriber)
+	assert.ErrorIs(t, failures[2], invalid)
+	assert.Contains(t, failures[2].Error(), "unable to publish")
+}
+
+func TestJournalWriteError(t *testing.T) {
+	failures := make(chan *HandlerError, 10)
+	journal := openJournal(t, t.TempDir())
+	d := NewDispatcher(WithJournal(journal), WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	var count atomic.Int32
+	defer Subscribe(d, func(ev MyEvent1) { count.Add(1) })()
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+
+	// Events published once the journal is closed are lost, so they are reported
+	assert.NoError(t, journal.Close())
+	Publish(d, MyEvent1{Number: 2})
+
+	err := <-failures
+	assert.Equal(t, MyEvent1{Number: 2}, err.Event)
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestJournalRetainedData(t *testing.T) {
+	journal := openJournal(t, t.TempDir())
+	defer journal.Close()
+
+	var seq atomic.Uint64
+	for i := 1; i <= 3; i++ {
+		_, err := journal.appendNext(TypeEvent1, &seq, 0, []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+
+	// Decoders may keep the data of the records they are given
+	var records [][]byte
+	assert.NoError(t, journal.scan(0, func(_ uint32, _ uint64, _ int64, data []byte) error {
+		records = append(records, data)
+		return nil
+	}))
+	assert.Equal(t, [][]byte{{1}, {2}, {3}}, records)
+}
+
+// openJournal opens a journal with a JSON codec for the first event type
+func openJournal(t *testing.T, dir string, opts ...JournalOption) *Journal {
+	journal, err := OpenJournal(dir, opts...)
+	assert.NoError(t, err)
+
+	RegisterCodec(journal, func(ev MyEvent1) ([]byte, error) {
+		return json.Marshal(ev)
+	}, func(b []byte) (ev MyEvent1, err error) {
+		err = json.Unmarshal(b, &ev)
+		return
+	})
+	return journal
+}
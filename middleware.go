@@ -0,0 +1,162 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"fmt"
+)
+
+// PublishFunc publishes an event along with its headers, which may be nil. It
+// returns an error if the event could not be published or was rejected.
+type PublishFunc func(ctx context.Context, ev Event, headers map[string]string) error
+
+// HandleFunc handles an event on behalf of a subscriber.
+type HandleFunc func(ctx context.Context, ev Event) error
+
+// Middleware intercepts the events flowing through a dispatcher, regardless of
+// their type. Either of the interceptors may be nil.
+//
+// The publish interceptor runs on the publisher's goroutine before the event is
+// queued. It can mutate the event by passing a different one of the same type to
+// the next function, reject it by returning an error without calling the next
+// function, or annotate it with headers. Since the headers may be shared with the
+// publisher, annotating requires passing a new or a copied map to the next function.
+//
+// The handle interceptor runs on the subscriber's goroutine around every handler,
+// which makes it suitable for timing, recovery, logging or tracing. Batch handlers
+// are not intercepted.
+type Middleware struct {
+	Publish func(next PublishFunc) PublishFunc
+	Handle  func(next HandleFunc) HandleFunc
+}
+
+// middleware represents the interceptors registered on a dispatcher
+type middleware struct {
+	publish []func(PublishFunc) PublishFunc
+	handle  []func(HandleFunc) HandleFunc
+}
+
+// Use registers the middleware on the dispatcher. Middleware registered first is
+// the outermost, and applies to events published after the call, including to
+// the subscribers that already exist.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Copy-on-write, so that publishers and subscribers never need a lock
+	next := new(middleware)
+	if prev := d.middleware.Load(); prev != nil {
+		next.publish = append(next.publish, prev.publish...)
+		next.handle = append(next.handle, prev.handle...)
+	}
+
+	for _, m := range mw {
+		if m.Publish != nil {
+			next.publish = append(next.publish, m.Publish)
+		}
+		if m.Handle != nil {
+			next.handle = append(next.handle, m.Handle)
+		}
+	}
+	d.middleware.Store(next)
+}
+
+// Publish broadcasts the message to the consumers of the group, after running it
+// through the publish interceptors of the dispatcher, if any.
+func (s *group[T]) Publish(msg message[T], block bool) error {
+	mw := s.owner.middleware.Load()
+	if mw == nil || len(mw.publish) == 0 {
+		return s.send(msg, block)
+	}
+
+	return s.intercept(mw, msg, block)
+}
+
+// intercept runs the message through the publish interceptors before broadcasting
+// it. This is kept separate so that the message only escapes when intercepted.
+func (s *group[T]) intercept(mw *middleware, msg message[T], block bool) error {
+	// Publishers without a context are given a background one
+	origin := context.Background()
+	var headers map[string]string
+	if msg.meta != nil {
+		headers = msg.meta.headers
+		if msg.meta.ctx != nil {
+			origin = msg.meta.ctx
+		}
+	}
+
+	next := PublishFunc(func(ctx context.Context, ev Event, headers map[string]string) error {
+		event, ok := ev.(T)
+		if !ok {
+			return errConflict[T](s.eventType, fmt.Sprintf("%T", ev))
+		}
+
+		msg.event = event
+		if ctx != origin || headers != nil {
+			if msg.meta == nil {
+				msg.meta = new(metadata)
+			}
+			if ctx != origin {
+				msg.meta.ctx = ctx
+			}
+			msg.meta.headers = headers
+		}
+		return s.send(msg, block)
+	})
+
+	for i := len(mw.publish) - 1; i >= 0; i-- {
+		next = mw.publish[i](next)
+	}
+	return next(origin, msg.event, headers)
+}
+
+// invoke calls the handler of the consumer, through the tracer and the handle
+// interceptors of the dispatcher, if any.
+func (s *consumer[T]) invoke(g *group[T], msg *message[T]) error {
+	mw, tracer := g.owner.middleware.Load(), g.owner.config.tracer
+	if s.internal || (tracer == nil && (mw == nil || len(mw.handle) == 0)) {
+		return s.handler(s.contextOf(msg), msg)
+	}
+
+	return s.intercept(g, mw, tracer, msg)
+}
+
+// intercept runs the handler of the consumer within a span of the tracer, and
+// through the handle interceptors.
+func (s *consumer[T]) intercept(g *group[T], mw *middleware, tracer Tracer, msg *message[T]) (err error) {
+	ctx := s.contextOf(msg)
+	if tracer != nil {
+		var span Span
+		ctx, span = tracer.Start(ctx, msg.meta.parent(), msg.event)
+		defer func() {
+			if r := recover(); r != nil {
+				span.End(fmt.Errorf("event: handler panicked: %v", r))
+				panic(r)
+			}
+			span.End(err)
+		}()
+	}
+
+	if mw == nil || len(mw.handle) == 0 {
+		return s.handler(ctx, msg)
+	}
+
+	next := HandleFunc(func(ctx context.Context, ev Event) error {
+		event, ok := ev.(T)
+		if !ok {
+			return errConflict[T](g.eventType, fmt.Sprintf("%T", ev))
+		}
+
+		// The message is shared with the other consumers, so mutate a copy
+		m := *msg
+		m.event = event
+		return s.handler(ctx, &m)
+	})
+
+	for i := len(mw.handle) - 1; i >= 0; i-- {
+		next = mw.handle[i](next)
+	}
+	return next(ctx, msg.event)
+}
@@ -0,0 +1,214 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareMutate(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				if e, ok := ev.(MyEvent1); ok {
+					ev = MyEvent1{Number: e.Number * 2}
+				}
+				return next(ctx, ev, headers)
+			}
+		},
+	})
+
+	var received []int
+	defer Subscribe(d, func(ev MyEvent1) {
+		received = append(received, ev.Number)
+	})()
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.Equal(t, []int{2, 4}, received)
+}
+
+func TestMiddlewareReject(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	invalid := errors.New("invalid")
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				if e, ok := ev.(MyEvent1); ok && e.Number < 0 {
+					return invalid
+				}
+				return next(ctx, ev, headers)
+			}
+		},
+	})
+
+	var received []int
+	defer Subscribe(d, func(ev MyEvent1) {
+		received = append(received, ev.Number)
+	})()
+
+	Publish(d, MyEvent1{Number: -1})
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{Number: -2}), invalid)
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{Number: -3}), invalid)
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, []int{1}, received)
+}
+
+func TestMiddlewareAnnotate(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				annotated := map[string]string{"region": "eu"}
+				for k, v := range headers {
+					annotated[k] = v
+				}
+				return next(ctx, ev, annotated)
+			}
+		},
+	})
+
+	received := make(chan Envelope[MyEvent1], 2)
+	defer SubscribeEnvelope(d, func(env Envelope[MyEvent1]) {
+		received <- env
+	})()
+
+	// The headers of the publisher must not be modified
+	headers := map[string]string{"tenant": "acme"}
+	Publish(d, MyEvent1{Number: 1})
+	PublishEnvelope(d, Envelope[MyEvent1]{Headers: headers, Event: MyEvent1{Number: 2}})
+
+	env1, env2 := <-received, <-received
+	assert.Equal(t, map[string]string{"region": "eu"}, env1.Headers)
+	assert.Equal(t, map[string]string{"region": "eu", "tenant": "acme"}, env2.Headers)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, headers)
+}
+
+func TestMiddlewareContext(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	type key struct{}
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				return next(context.WithValue(ctx, key{}, "value"), ev, headers)
+			}
+		},
+	})
+
+	received := make(chan any, 1)
+	defer SubscribeCtx(context.Background(), d, func(ctx context.Context, ev MyEvent1) {
+		received <- ctx.Value(key{})
+	})()
+
+	Publish(d, MyEvent1{})
+	assert.Equal(t, "value", <-received)
+}
+
+func TestMiddlewareConflict(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				return next(ctx, MyEvent2{}, headers)
+			}
+		},
+	})
+
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), ErrConflict)
+}
+
+func TestMiddlewareHandle(t *testing.T) {
+	var failures []*HandlerError
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures = append(failures, err)
+	}))
+	defer d.Close()
+
+	// Subscribers that already exist are intercepted too
+	var count int
+	defer Subscribe(d, func(ev MyEvent1) {
+		if ev.Number < 0 {
+			panic("boom")
+		}
+		count++
+	})()
+
+	var mu sync.Mutex
+	var trace []string
+	record := func(name string) Middleware {
+		return Middleware{
+			Handle: func(next HandleFunc) HandleFunc {
+				return func(ctx context.Context, ev Event) error {
+					mu.Lock()
+					trace = append(trace, name)
+					mu.Unlock()
+					return next(ctx, ev)
+				}
+			},
+		}
+	}
+
+	d.Use(record("outer"), record("inner"), Middleware{
+		Handle: func(next HandleFunc) HandleFunc {
+			return func(ctx context.Context, ev Event) (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("recovered: %v", r)
+					}
+				}()
+				return next(ctx, ev)
+			}
+		},
+	})
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Error(t, PublishSync(d, MyEvent1{Number: -1}))
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"outer", "inner", "outer", "inner", "outer", "inner"}, trace)
+
+	// The panic was turned into an error, the subscriber is still alive
+	assert.Len(t, failures, 1)
+	assert.Nil(t, failures[0].Panic)
+	assert.EqualError(t, failures[0].Err, "recovered: boom")
+}
+
+func TestMiddlewareOrdered(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count int
+	d.Use(Middleware{
+		Handle: func(next HandleFunc) HandleFunc {
+			return func(ctx context.Context, ev Event) error {
+				count++
+				return next(ctx, ev)
+			}
+		},
+	})
+
+	// Every member of the chain is intercepted, but not the chain itself
+	defer Subscribe(d, func(ev MyEvent1) {}, WithPriority(2))()
+	defer Subscribe(d, func(ev MyEvent1) {}, WithPriority(1))()
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,401 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Option represents a dispatcher configuration option.
+type Option func(*config)
+
+// config represents the configuration of a dispatcher or of a specific event type
+type config struct {
+	interval time.Duration       // Flush interval
+	maxQueue int                 // Maximum queue size per consumer
+	capacity int                 // Initial queue capacity per consumer
+	types    map[uint32][]Option // Per-event type overrides
+	onError  func(*HandlerError) // Error handler for failed handlers
+	timing   bool                // Record the duration of the handlers
+	tracer   Tracer              // Tracer of the handlers
+	journal  *Journal            // Journal of the published events
+	history  int                 // Number of events retained for replay
+	retain   bool                // Deliver the last event to new subscribers
+	retained bool                // Whether any event type is retained
+}
+
+// newConfig creates a new configuration with the defaults and applies the options
+func newConfig(opts []Option) config {
+	c := config{
+		interval: 500 * time.Microsecond,
+		maxQueue: 50000, // 50k * 20 (df) = 1 million events / second
+		capacity: 64,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	// Events of the retained types are kept even before anybody subscribed
+	c.retained = c.retains() > 0
+	for eventType := range c.types {
+		c.retained = c.retained || c.configFor(eventType).retains() > 0
+	}
+	return c
+}
+
+// retains returns the number of events retained per event type
+func (c config) retains() int {
+	if c.retain && c.history == 0 {
+		return 1
+	}
+	return c.history
+}
+
+// configFor returns the configuration for a specific event type, with the
+// per-type overrides applied on top of the dispatcher-wide configuration.
+func (c config) configFor(eventType uint32) config {
+	overrides, ok := c.types[eventType]
+	if !ok {
+		return c
+	}
+
+	out := c
+	out.types = nil // Nested overrides are not supported
+	for _, opt := range overrides {
+		opt(&out)
+	}
+	return out
+}
+
+// WithInterval sets the interval at which queued events are flushed to the
+// consumers. Lower intervals reduce latency at the cost of more wake-ups.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithMaxQueue sets the maximum number of events that can be queued for a single
+// consumer before the publisher experiences backpressure.
+func WithMaxQueue(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.maxQueue = size
+		}
+	}
+}
+
+// WithQueueCapacity sets the initial capacity of the queue allocated for each
+// consumer. This is only a hint, queues grow as needed up to the maximum size.
+func WithQueueCapacity(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.capacity = size
+		}
+	}
+}
+
+// WithType overrides the configuration for a specific event type. This allows,
+// for example, latency-sensitive event types to use a tighter flush interval than
+// bulk event types on the same dispatcher.
+func WithType(eventType uint32, opts ...Option) Option {
+	return func(c *config) {
+		if c.types == nil {
+			c.types = make(map[uint32][]Option, 4)
+		}
+
+		c.types[eventType] = append(c.types[eventType], opts...)
+	}
+}
+
+// WithOnError sets the error handler of the dispatcher. It is called whenever a
+// handler returns an error or panics, from the goroutine of the subscriber. It is
+// also called from the publisher, with a subscriber of zero, when an event could
+// not be journaled and was therefore not delivered. Without an error handler,
+// failures are silently discarded.
+func WithOnError(handler func(*HandlerError)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithTiming records the duration of every handler in a histogram, available
+// through the Stats of the dispatcher. This requires reading the clock for every
+// handled event, which is why it is disabled by default.
+func WithTiming() Option {
+	return func(c *config) {
+		c.timing = true
+	}
+}
+
+// WithTracer sets the tracer of the dispatcher. The span context of the publisher
+// is captured when an event is published, and a child span is started around
+// every handler of the event.
+func WithTracer(tracer Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// WithJournal attaches a journal to the dispatcher, so that the published events
+// are appended to it before being delivered, even if nobody subscribed to their
+// type yet. Sequence numbers of the dispatcher continue from the last event of the
+// journal.
+func WithJournal(journal *Journal) Option {
+	return func(c *config) {
+		c.journal = journal
+	}
+}
+
+// WithHistory retains the most recent events of the dispatcher, up to the specified
+// number per event type, so they can be replayed to new subscribers with
+// SubscribeFrom. It is typically applied to specific event types with WithType.
+func WithHistory(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.history = size
+		}
+	}
+}
+
+// WithRetain remembers the most recent event and delivers it to every new subscriber
+// before any live event, which suits state-like events such as configuration
+// changes or feature flags. It is typically applied to specific event types with
+// WithType. Within a queue group, the event is only delivered to the member that
+// creates the queue group.
+func WithRetain() Option {
+	return func(c *config) {
+		c.retain = true
+	}
+}
+
+// ------------------------------------- Subscription -------------------------------------
+
+// Overflow represents a policy applied when a subscriber's queue is full.
+type Overflow uint8
+
+// Various overflow policies
+const (
+	OverflowBlock      Overflow = iota // Block the publisher until the subscriber catches up
+	OverflowDropNewest                 // Drop the event being published for this subscriber
+	OverflowDropOldest                 // Drop the oldest queued event to make room for the new one
+	OverflowEvict                      // Unsubscribe the slow subscriber altogether
+)
+
+// SubscribeOption represents a subscription configuration option.
+type SubscribeOption func(*subscription)
+
+// subscription represents the configuration of a single subscription
+type subscription struct {
+	ctx        context.Context // Context of the subscription
+	overflow   Overflow        // Overflow policy
+	onEvict    func()          // Eviction callback
+	keepAlive  bool            // Keep alive after a panic
+	stamp      bool            // Needs the publish time
+	filter     any             // Typed predicate, func(T) bool
+	queue      string          // Name of the queue group
+	label      string          // Label of the subscriber
+	priority   int             // Priority within the ordered chain
+	ordered    bool            // Whether the subscriber is ordered by priority
+	workers    int             // Number of concurrent workers
+	key        any             // Typed ordering key, func(T) uint64
+	bulk       any             // Typed batch handler, func([]T)
+	maxBatch   int             // Maximum number of events per batch
+	maxWait    time.Duration   // Maximum time to wait for a batch to fill up
+	retry      RetryPolicy     // Retry policy of the failed events
+	deadLetter bool            // Whether dead letters are published
+	letters    *DeadLetters    // Queue the dead letters are added to, if any
+	replay     bool            // Whether the retained events are replayed
+	position   uint64          // Sequence number to replay the events from
+}
+
+// newSubscription creates a new subscription configuration and applies the options
+func newSubscription(opts []SubscribeOption) subscription {
+	s := subscription{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// validate returns ErrUnsupported if some of the options can not be combined
+func (s *subscription) validate() error {
+	switch {
+	case s.bulk != nil && s.retry.MaxAttempts > 1:
+		return fmt.Errorf("%w: batch handlers can not retry events", ErrUnsupported)
+	case !s.ordered || s.bulk != nil:
+		return nil
+	}
+
+	// Ordered subscribers share the queue of the chain, which always blocks
+	switch {
+	case s.replay:
+		return fmt.Errorf("%w: ordered subscribers can not replay events", ErrUnsupported)
+	case s.deadLetter || s.letters != nil:
+		return fmt.Errorf("%w: ordered subscribers can not dead-letter events", ErrUnsupported)
+	case s.retry.MaxAttempts > 1:
+		return fmt.Errorf("%w: ordered subscribers can not retry events", ErrUnsupported)
+	case s.queue != "":
+		return fmt.Errorf("%w: ordered subscribers can not join a queue group", ErrUnsupported)
+	case s.overflow != OverflowBlock:
+		return fmt.Errorf("%w: ordered subscribers always block the publisher", ErrUnsupported)
+	case s.workers > 1:
+		return fmt.Errorf("%w: ordered subscribers can not have several workers", ErrUnsupported)
+	default:
+		return nil
+	}
+}
+
+// WithOverflow sets the policy applied when the subscriber's queue is full. By
+// default, the publisher is blocked until the subscriber catches up.
+func WithOverflow(policy Overflow) SubscribeOption {
+	return func(s *subscription) {
+		s.overflow = policy
+	}
+}
+
+// WithEviction evicts the subscriber when its queue is full instead of blocking
+// the publisher. The callback is invoked once the subscriber has been removed,
+// and any events remaining in its queue are discarded.
+func WithEviction(callback func()) SubscribeOption {
+	return func(s *subscription) {
+		s.overflow = OverflowEvict
+		s.onEvict = callback
+	}
+}
+
+// WithKeepAlive keeps the subscriber alive after its handler panics. By default,
+// a subscriber whose handler panics is unsubscribed and its queued events are
+// discarded, after the panic is reported to the error handler of the dispatcher.
+func WithKeepAlive() SubscribeOption {
+	return func(s *subscription) {
+		s.keepAlive = true
+	}
+}
+
+// WithFilter only delivers the events that match the predicate to the subscriber.
+// The predicate is evaluated by the publisher while holding the lock of the event
+// type, so it must be fast and must not publish events itself. Events that do not
+// match never occupy the queue of the subscriber nor cause any backpressure.
+func WithFilter[T Event](predicate func(T) bool) SubscribeOption {
+	return func(s *subscription) {
+		s.filter = predicate
+	}
+}
+
+// WithQueue makes the subscriber a member of a named queue group. Each event is
+// delivered to a single member of the queue group rather than to all of them,
+// which allows load-balancing work across several subscribers. Queue groups
+// coexist with regular subscribers of the same event type.
+func WithQueue(name string) SubscribeOption {
+	return func(s *subscription) {
+		s.queue = name
+	}
+}
+
+// WithConcurrency processes the queue of the subscriber with several workers, so a
+// slow handler no longer holds back every other event. Events are handled in no
+// particular order, unless an ordering key is provided with WithOrderingKey. Each
+// worker picks up the next event as soon as it is done with the previous one.
+func WithConcurrency(workers int) SubscribeOption {
+	return func(s *subscription) {
+		if workers > 0 {
+			s.workers = workers
+		}
+	}
+}
+
+// WithOrderingKey preserves the order of the events sharing the same key when the
+// subscriber has several workers. Events with the same key, for example the same
+// user id, are always handled in order by the same worker, while events with
+// different keys are handled in parallel.
+func WithOrderingKey[T Event](key func(T) uint64) SubscribeOption {
+	return func(s *subscription) {
+		s.key = key
+	}
+}
+
+// WithMaxBatch limits the number of events handed to a batch handler at once. By
+// default, a batch handler receives every event queued since its previous call.
+func WithMaxBatch(size int) SubscribeOption {
+	return func(s *subscription) {
+		if size > 0 {
+			s.maxBatch = size
+		}
+	}
+}
+
+// WithMaxWait lets a batch handler wait for up to the specified duration after the
+// first event of a batch was queued, so that the batch fills up to its maximum
+// size. Without it, the batch handler is called as soon as any event is queued.
+// The wait is only as precise as the flush interval of the dispatcher.
+func WithMaxWait(wait time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		if wait > 0 {
+			s.maxWait = wait
+		}
+	}
+}
+
+// WithPriority makes the subscriber part of the ordered chain of its event type.
+// Each event is handled by the ordered subscribers one after another, from the
+// highest priority to the lowest, while subscribers with equal priorities are
+// handled in the order they subscribed. If an ordered subscriber fails, the event
+// is not handed to the subscribers with a lower priority. For example, this allows
+// validating an event before persisting it and before notifying about it. As the
+// ordered subscribers share a single queue which blocks the publisher when full,
+// it can not be combined with WithQueue, WithOverflow, WithEviction, WithConcurrency,
+// WithReplay, WithRetry nor with the dead letter options.
+func WithPriority(priority int) SubscribeOption {
+	return func(s *subscription) {
+		s.priority = priority
+		s.ordered = true
+	}
+}
+
+// WithLabel labels the subscriber, for example with the name of the component it
+// belongs to. Labels are only used for introspection, see Dispatcher.Topics.
+func WithLabel(label string) SubscribeOption {
+	return func(s *subscription) {
+		s.label = label
+	}
+}
+
+// WithRetry retries the events whose handler returned an error, according to the
+// policy. Unless the policy is ordered or the subscriber has an ordering key, the
+// following events are handled while a failed event waits for its next attempt,
+// so a transient failure does not hold back unrelated events. Once unsubscribed or
+// aborted, the events waiting for their next attempt fail with the error of their
+// last attempt. It can not be combined with batch handlers nor with WithPriority.
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(s *subscription) {
+		s.retry = policy
+	}
+}
+
+// WithDeadLetter publishes a DeadLetter event on the dispatcher for every event the
+// subscriber did not handle: once its handler ran out of attempts, if its publish
+// context expired before it was handled, or if it was dropped from the queue of
+// the subscriber. Dead letters are published without blocking the subscriber, and
+// those that can not be published are reported to the error handler. It can not
+// be combined with WithPriority, as ordered subscribers share their queue.
+func WithDeadLetter() SubscribeOption {
+	return func(s *subscription) {
+		s.deadLetter = true
+	}
+}
+
+// WithDeadLetterQueue adds the dead letters of the subscriber directly to the queue,
+// see WithDeadLetter. Unlike published dead letters, they are added even once the
+// dispatcher is closed, for example for the events abandoned on shutdown.
+func WithDeadLetterQueue(queue *DeadLetters) SubscribeOption {
+	return func(s *subscription) {
+		s.letters = queue
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsDefault(t *testing.T) {
+	c := newConfig(nil)
+	assert.Equal(t, 500*time.Microsecond, c.interval)
+	assert.Equal(t, 50000, c.maxQueue)
+	assert.Equal(t, 64, c.capacity)
+}
+
+func TestOptionsOverride(t *testing.T) {
+	c := newConfig([]Option{
+		WithInterval(time.Millisecond),
+		WithMaxQueue(100),
+		WithQueueCapacity(8),
+		WithType(TypeEvent2,
+			WithInterval(50*time.Microsecond),
+			WithMaxQueue(10),
+		),
+	})
+
+	// Dispatcher-wide configuration
+	c1 := c.configFor(TypeEvent1)
+	assert.Equal(t, time.Millisecond, c1.interval)
+	assert.Equal(t, 100, c1.maxQueue)
+	assert.Equal(t, 8, c1.capacity)
+
+	// Per-type configuration
+	c2 := c.configFor(TypeEvent2)
+	assert.Equal(t, 50*time.Microsecond, c2.interval)
+	assert.Equal(t, 10, c2.maxQueue)
+	assert.Equal(t, 8, c2.capacity)
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	c := newConfig([]Option{
+		WithInterval(-1),
+		WithMaxQueue(0),
+		WithQueueCapacity(-5),
+	})
+
+	assert.Equal(t, newConfig(nil), c)
+}
+
+func TestOptionsPerType(t *testing.T) {
+	d := NewDispatcher(
+		WithMaxQueue(100),
+		WithType(TypeEvent2, WithMaxQueue(10), WithQueueCapacity(4)),
+	)
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	defer Subscribe(d, func(ev MyEvent1) { wg.Done() })()
+	defer Subscribe(d, func(ev MyEvent2) { wg.Done() })()
+
+	assert.Equal(t, 100, d.findGroup(TypeEvent1).(*group[MyEvent1]).maxQueue)
+	assert.Equal(t, 10, d.findGroup(TypeEvent2).(*group[MyEvent2]).maxQueue)
+	assert.Equal(t, 4, d.findGroup(TypeEvent2).(*group[MyEvent2]).capacity)
+
+	Publish(d, MyEvent1{})
+	Publish(d, MyEvent2{})
+	wg.Wait()
+}
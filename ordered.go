@@ -0,0 +1,152 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// chain represents the ordered subscribers of a group, which are all handled one
+// after another by a single consumer, the runner, in the order of their priority.
+type chain[T Event] struct {
+	owner   *group[T]                      // Owning group
+	runner  *consumer[T]                   // Consumer running the chain
+	members atomic.Pointer[[]*consumer[T]] // Members, by descending priority
+}
+
+// chainTo adds the consumer to the ordered chain of the group, creating the chain
+// if necessary. It returns the runner of the chain if it needs to be started. This
+// must be called while holding the lock of the group.
+func (s *group[T]) chainTo(sub *consumer[T], priority int) (runner *consumer[T]) {
+	if s.chain == nil {
+		s.chain = &chain[T]{owner: s}
+		s.chain.members.Store(&[]*consumer[T]{})
+		runner = &consumer[T]{
+			queue:     make([]message[T], 0, s.capacity),
+			id:        s.owner.nextID.Add(1),
+			ctx:       context.Background(),
+			handler:   s.chain.handle,
+			keepAlive: true,
+			internal:  true,
+		}
+
+		s.chain.runner = runner
+		s.subs = append(s.subs, runner)
+	}
+
+	// Copy-on-write, so the runner can walk the members without the lock
+	sub.priority = priority
+	sub.chained = true
+	sub.queue = nil
+	prev := *s.chain.members.Load()
+	next := make([]*consumer[T], 0, len(prev)+1)
+	at := len(prev)
+	for i, v := range prev {
+		if v.priority < priority {
+			at = i
+			break
+		}
+	}
+
+	next = append(next, prev[:at]...)
+	next = append(next, sub)
+	next = append(next, prev[at:]...)
+	s.chain.members.Store(&next)
+	if sub.stamp {
+		s.stamps++
+	}
+	return
+}
+
+// unchain removes the consumer from the ordered chain of the group, stopping the
+// runner once the chain is empty. This must be called while holding the lock.
+func (s *group[T]) unchain(sub *consumer[T]) {
+	if s.chain == nil {
+		return
+	}
+
+	prev := *s.chain.members.Load()
+	next := make([]*consumer[T], 0, len(prev))
+	for _, v := range prev {
+		if v != sub {
+			next = append(next, v)
+		}
+	}
+
+	// Already removed, for example after a panic
+	if len(next) == len(prev) {
+		return
+	}
+
+	s.chain.members.Store(&next)
+	if sub.stamp {
+		s.stamps--
+	}
+
+	if len(next) == 0 {
+		runner := s.chain.runner
+		s.chain = nil
+		s.remove(runner)
+	}
+}
+
+// size returns the number of members of the chain
+func (c *chain[T]) size() int {
+	return len(*c.members.Load())
+}
+
+// handle hands the event to every member of the chain by descending priority and
+// stops at the first member that fails.
+func (c *chain[T]) handle(_ context.Context, m *message[T]) error {
+	for _, sub := range *c.members.Load() {
+		if !sub.accepts(m.event) || !m.targets(sub) {
+			continue
+		}
+
+		failure := c.call(sub, m)
+		sub.handled(c.owner, 1)
+		if failure != nil {
+			sub.failures(c.owner, 1)
+			if failure.Panic != nil && !sub.keepAlive {
+				c.owner.Del(sub)
+			}
+			return failure
+		}
+	}
+	return nil
+}
+
+// targets returns whether the message is delivered to the member of the chain,
+// which is the case unless the message targets a single member.
+func (m *message[T]) targets(sub *consumer[T]) bool {
+	return m.meta == nil || m.meta.target == nil || m.meta.target == any(sub)
+}
+
+// call invokes the handler of a member of the chain, recovering from a panic.
+func (c *chain[T]) call(sub *consumer[T], m *message[T]) (failure *HandlerError) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure = newPanicError(c.owner.eventType, sub.id, m.event, r)
+		}
+	}()
+
+	if c.owner.timing {
+		start := time.Now()
+		defer func() {
+			sub.durations.observe(time.Since(start))
+		}()
+	}
+
+	if err := sub.invoke(c.owner, m); err != nil {
+		return &HandlerError{
+			Type:       c.owner.eventType,
+			Subscriber: sub.id,
+			Event:      m.event,
+			Err:        err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	order := make(map[int][]string)
+	handler := func(name string) func(MyEvent1) {
+		return func(ev MyEvent1) {
+			mu.Lock()
+			order[ev.Number] = append(order[ev.Number], name)
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+
+	// Subscribe in a scrambled order
+	const events = 100
+	wg.Add(events * 4)
+	defer Subscribe(d, handler("notify"), WithPriority(1))()
+	defer Subscribe(d, handler("validate"), WithPriority(10))()
+	defer Subscribe(d, handler("persist"), WithPriority(5))()
+	defer Subscribe(d, handler("audit"), WithPriority(5))()
+	assert.Equal(t, 4, d.count(TypeEvent1))
+
+	for i := 0; i < events; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	wg.Wait()
+	for i := 0; i < events; i++ {
+		assert.Equal(t, []string{"validate", "persist", "audit", "notify"}, order[i])
+	}
+}
+
+func TestPriorityFailure(t *testing.T) {
+	var failures []*HandlerError
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures = append(failures, err)
+	}))
+	defer d.Close()
+
+	invalid := errors.New("invalid")
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if ev.Number < 0 {
+			return invalid
+		}
+		return nil
+	}, WithPriority(10))()
+
+	var persisted []int
+	defer Subscribe(d, func(ev MyEvent1) {
+		persisted = append(persisted, ev.Number)
+	}, WithPriority(5))()
+
+	// Invalid events never reach the lower priority subscribers
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{Number: -1}), invalid)
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.Equal(t, []int{1, 2}, persisted)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, MyEvent1{Number: -1}, failures[0].Event)
+}
+
+func TestPriorityPanic(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count int
+	Subscribe(d, func(ev MyEvent1) {
+		panic("boom")
+	}, WithPriority(10))
+	defer Subscribe(d, func(ev MyEvent1) {
+		count++
+	}, WithPriority(5))()
+
+	// The panicking subscriber is removed, the others keep going
+	assert.Error(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 2}))
+	assert.Equal(t, 1, count)
+}
+
+func TestPriorityMixed(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	cancel1 := Subscribe(d, func(ev MyEvent1) { wg.Done() }, WithPriority(1))
+	cancel2 := Subscribe(d, func(ev MyEvent1) { wg.Done() }, WithPriority(2))
+	defer Subscribe(d, func(ev MyEvent1) { wg.Done() })()
+	assert.Equal(t, 3, d.count(TypeEvent1))
+
+	Publish(d, MyEvent1{})
+	wg.Wait()
+
+	// Once the chain is empty, its runner is stopped
+	cancel1()
+	cancel2()
+	grp := d.findGroup(TypeEvent1).(*group[MyEvent1])
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	assert.Nil(t, grp.chain)
+	assert.Len(t, grp.subs, 1)
+}
+
+func TestPriorityUnsupported(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// The ordered subscribers share the blocking queue of the chain
+	for _, opt := range []SubscribeOption{
+		WithQueue("q"),
+		WithOverflow(OverflowDropNewest),
+		WithEviction(nil),
+		WithConcurrency(2),
+	} {
+		_, err := TrySubscribe(d, func(ev MyEvent1) {}, WithPriority(1), opt)
+		assert.ErrorIs(t, err, ErrUnsupported)
+	}
+
+	assert.Panics(t, func() {
+		Subscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithQueue("q"))
+	})
+	assert.Equal(t, 0, d.count(TypeEvent1))
+}
+
+func TestPriorityEnvelope(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	out := make(chan Envelope[MyEvent1], 1)
+	defer SubscribeEnvelope(d, func(e Envelope[MyEvent1]) {
+		out <- e
+	}, WithPriority(1))()
+
+	Publish(d, MyEvent1{Number: 1})
+	select {
+	case e := <-out:
+		assert.NotZero(t, e.Time)
+	case <-time.After(time.Second):
+		assert.Fail(t, "timeout")
+	}
+}
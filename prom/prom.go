@@ -0,0 +1,145 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+// Package prom exposes the statistics of an event dispatcher in the Prometheus text
+// exposition format, without depending on the Prometheus client library.
+package prom
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/kelindar/event"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler returns an HTTP handler rendering the statistics of the dispatcher in
+// the Prometheus text exposition format, so that it can be scraped.
+func Handler(dispatcher *event.Dispatcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		Write(w, dispatcher.Stats())
+	})
+}
+
+// metric represents a metric family rendered for every event type
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(event.TypeStats) float64
+}
+
+// metrics are the per-type metric families
+var metrics = []metric{
+	{"event_published_total", "counter", "Number of events published.", func(s event.TypeStats) float64 {
+		return float64(s.Published)
+	}},
+	{"event_delivered_total", "counter", "Number of events handed to the handlers of the subscribers.", func(s event.TypeStats) float64 {
+		return float64(s.Delivered)
+	}},
+	{"event_failed_total", "counter", "Number of events for which a handler failed or panicked.", func(s event.TypeStats) float64 {
+		return float64(s.Failed)
+	}},
+	{"event_dropped_total", "counter", "Number of events dropped or discarded for the subscribers.", func(s event.TypeStats) float64 {
+		return float64(s.Dropped)
+	}},
+	{"event_backpressure_seconds_total", "counter", "Total time publishers were blocked by full queues.", func(s event.TypeStats) float64 {
+		return s.Backpressure.Seconds()
+	}},
+	{"event_subscribers", "gauge", "Number of subscribers.", func(s event.TypeStats) float64 {
+		return float64(len(s.Subscribers))
+	}},
+	{"event_queue_depth", "gauge", "Number of events queued or being processed.", func(s event.TypeStats) float64 {
+		return float64(s.Queued)
+	}},
+	{"event_queue_high_water", "gauge", "Highest queue length observed for any subscriber.", func(s event.TypeStats) float64 {
+		return float64(s.HighWater)
+	}},
+}
+
+// Write renders the statistics in the Prometheus text exposition format.
+func Write(dst io.Writer, stats []event.TypeStats) error {
+	w := bufio.NewWriter(dst)
+	for _, m := range metrics {
+		header(w, m.name, m.kind, m.help)
+		for _, s := range stats {
+			sample(w, m.name, m.value(s), "type", typeOf(s))
+		}
+	}
+
+	// Per-subscriber queue depth
+	header(w, "event_subscriber_queue_depth", "gauge", "Number of events queued or being processed by the subscriber.")
+	for _, s := range stats {
+		for _, sub := range s.Subscribers {
+			sample(w, "event_subscriber_queue_depth", float64(sub.Queued), "type", typeOf(s), "subscriber", idOf(sub))
+		}
+	}
+
+	// Per-subscriber handler durations, only if they are recorded
+	header(w, "event_handler_duration_seconds", "histogram", "Duration of the handlers of the subscribers.")
+	for _, s := range stats {
+		for _, sub := range s.Subscribers {
+			if sub.Duration.Count > 0 {
+				histogram(w, "event_handler_duration_seconds", sub.Duration, "type", typeOf(s), "subscriber", idOf(sub))
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// header writes the help and type lines of a metric family
+func header(w *bufio.Writer, name, kind, help string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// sample writes a single sample with its labels, provided as name and value pairs
+func sample(w *bufio.Writer, name string, value float64, labels ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i] + `="` + labels[i+1] + `"`)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteByte('\n')
+}
+
+// histogram writes the cumulative buckets, the sum and the count of a histogram
+func histogram(w *bufio.Writer, name string, h event.Histogram, labels ...string) {
+	var total uint64
+	for i, count := range h.Counts {
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = strconv.FormatFloat(h.Bounds[i].Seconds(), 'g', -1, 64)
+		}
+
+		total += count
+		sample(w, name+"_bucket", float64(total), append(labels, "le", le)...)
+	}
+
+	sample(w, name+"_sum", h.Sum.Seconds(), labels...)
+	sample(w, name+"_count", float64(h.Count), labels...)
+}
+
+// typeOf returns the label value of an event type
+func typeOf(s event.TypeStats) string {
+	return strconv.FormatUint(uint64(s.Type), 10)
+}
+
+// idOf returns the label value of a subscriber
+func idOf(s event.SubscriberStats) string {
+	return strconv.FormatUint(s.ID, 10)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package prom
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type Ping struct{}
+
+func (Ping) Type() uint32 { return 0x10 }
+
+type Pong struct{}
+
+func (Pong) Type() uint32 { return 0x20 }
+
+func TestHandler(t *testing.T) {
+	d := event.NewDispatcher(event.WithTiming())
+	defer d.Close()
+
+	defer event.Subscribe(d, func(ev Ping) {})()
+	defer event.Subscribe(d, func(ev Ping) {})()
+	defer event.Subscribe(d, func(ev Pong) {})()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, event.PublishSync(d, Ping{}))
+	}
+
+	server := httptest.NewServer(Handler(d))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+
+	out := string(body)
+	assert.Contains(t, out, "# TYPE event_published_total counter\n")
+	assert.Contains(t, out, `event_published_total{type="16"} 3`+"\n")
+	assert.Contains(t, out, `event_published_total{type="32"} 0`+"\n")
+	assert.Contains(t, out, `event_delivered_total{type="16"} 6`+"\n")
+	assert.Contains(t, out, `event_subscribers{type="16"} 2`+"\n")
+	assert.Contains(t, out, `event_subscribers{type="32"} 1`+"\n")
+	assert.Contains(t, out, `event_queue_depth{type="16"} 0`+"\n")
+	assert.Contains(t, out, "# TYPE event_handler_duration_seconds histogram\n")
+	assert.Contains(t, out, `le="+Inf"} 3`+"\n")
+}
+
+func TestWrite(t *testing.T) {
+	var out strings.Builder
+	assert.NoError(t, Write(&out, []event.TypeStats{{
+		Type:         1,
+		Published:    10,
+		Backpressure: 1500 * time.Millisecond,
+		Subscribers: []event.SubscriberStats{{
+			ID:     7,
+			Queued: 2,
+			Duration: event.Histogram{
+				Bounds: []time.Duration{time.Millisecond, time.Second},
+				Counts: []uint64{1, 2, 3},
+				Count:  6,
+				Sum:    2 * time.Second,
+			},
+		}},
+	}}))
+
+	// Every sample line must be a name with optional labels and a value
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			assert.Len(t, strings.Fields(line), 2, line)
+		}
+	}
+
+	assert.Contains(t, out.String(), `event_backpressure_seconds_total{type="1"} 1.5`+"\n")
+	assert.Contains(t, out.String(), `event_subscriber_queue_depth{type="1",subscriber="7"} 2`+"\n")
+	assert.Contains(t, out.String(), ""+
+		`event_handler_duration_seconds_bucket{type="1",subscriber="7",le="0.001"} 1`+"\n"+
+		`event_handler_duration_seconds_bucket{type="1",subscriber="7",le="1"} 3`+"\n"+
+		`event_handler_duration_seconds_bucket{type="1",subscriber="7",le="+Inf"} 6`+"\n"+
+		`event_handler_duration_seconds_sum{type="1",subscriber="7"} 2`+"\n"+
+		`event_handler_duration_seconds_count{type="1",subscriber="7"} 6`+"\n")
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+)
+
+// SubscribeQueue subscribes to an event as a member of a named queue group, the
+// type of the event will be automatically inferred from the provided type. Each
+// event is handled by a single member of the queue group, the least loaded one.
+func SubscribeQueue[T Event](broker *Dispatcher, name string, handler func(T), opts ...SubscribeOption) context.CancelFunc {
+	return Subscribe(broker, handler, append(opts, WithQueue(name))...)
+}
+
+// queueGroup represents a set of competing consumers within a group
+type queueGroup[T Event] struct {
+	name    string         // Name of the queue group
+	members []*consumer[T] // Members of the queue group
+	next    int            // Rotating starting point for the selection
+}
+
+// pick selects the member to deliver the event to and rotates the starting point,
+// so equally loaded members are picked in a round-robin fashion.
+func (q *queueGroup[T]) pick(ev T) *consumer[T] {
+	best := q.least(ev)
+	q.next = (q.next + 1) % len(q.members)
+	return best
+}
+
+// least returns the least loaded member accepting the event, counting both its
+// queue and the events it is currently processing.
+func (q *queueGroup[T]) least(ev T) (best *consumer[T]) {
+	n, load := len(q.members), 0
+	for i := 0; i < n; i++ {
+		sub := q.members[(q.next+i)%n]
+		if !sub.accepts(ev) {
+			continue
+		}
+
+		if l := len(sub.queue) + int(sub.inflight.Load()); best == nil || l < load {
+			best, load = sub, l
+		}
+	}
+	return
+}
+
+// join adds the consumer to the queue group with the name, creating it if needed.
+// It returns whether the queue group was created. This must be called while holding
+// the group lock.
+func (s *group[T]) join(sub *consumer[T], name string) bool {
+	for _, q := range s.shared {
+		if q.name == name {
+			q.members = append(q.members, sub)
+			sub.shared = q
+			return false
+		}
+	}
+
+	q := &queueGroup[T]{name: name, members: []*consumer[T]{sub}}
+	s.shared = append(s.shared, q)
+	sub.shared = q
+	return true
+}
+
+// leave removes the consumer from its queue group, removing the queue group once
+// it has no members left. This must be called while holding the group lock.
+func (s *group[T]) leave(sub *consumer[T]) {
+	q := sub.shared
+	for i, v := range q.members {
+		if v == sub {
+			q.members = append(q.members[:i], q.members[i+1:]...)
+			break
+		}
+	}
+
+	if q.next >= len(q.members) {
+		q.next = 0
+	}
+
+	if len(q.members) > 0 {
+		return
+	}
+
+	for i, v := range s.shared {
+		if v == q {
+			s.shared = append(s.shared[:i], s.shared[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeQueue(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	const workers, events = 4, 1000
+	var wg sync.WaitGroup
+	var total atomic.Int64
+	counts := make([]atomic.Int64, workers)
+	wg.Add(events * 2)
+
+	// Competing consumers
+	for i := 0; i < workers; i++ {
+		i := i
+		defer SubscribeQueue(d, "workers", func(ev MyEvent1) {
+			counts[i].Add(1)
+			total.Add(1)
+			wg.Done()
+		})()
+	}
+
+	// Regular fan-out subscriber on the same type
+	var fanout atomic.Int64
+	defer Subscribe(d, func(ev MyEvent1) {
+		fanout.Add(1)
+		wg.Done()
+	})()
+
+	for i := 0; i < events; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(events), total.Load())
+	assert.Equal(t, int64(events), fanout.Load())
+	for i := 0; i < workers; i++ {
+		assert.Greater(t, counts[i].Load(), int64(0))
+	}
+}
+
+func TestSubscribeQueueLeastLoaded(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// One member gets stuck on its first event, the other must get the rest
+	release := make(chan struct{})
+	handled := make(chan bool, 1)
+	defer close(release)
+	SubscribeQueue(d, "workers", func(ev MyEvent1) {
+		handled <- false
+		<-release
+	})
+
+	fast := 0
+	defer SubscribeQueue(d, "workers", func(ev MyEvent1) {
+		handled <- true
+	})()
+
+	for i := 0; i < 20; i++ {
+		Publish(d, MyEvent1{Number: i})
+		if <-handled {
+			fast++
+		}
+	}
+
+	assert.Equal(t, 19, fast)
+}
+
+func TestSubscribeQueueSeparate(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// Two different queue groups both receive every event
+	var wg sync.WaitGroup
+	var a, b atomic.Int64
+	wg.Add(20)
+	defer SubscribeQueue(d, "a", func(ev MyEvent1) { a.Add(1); wg.Done() })()
+	defer SubscribeQueue(d, "a", func(ev MyEvent1) { a.Add(1); wg.Done() })()
+	defer SubscribeQueue(d, "b", func(ev MyEvent1) { b.Add(1); wg.Done() })()
+
+	for i := 0; i < 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(10), a.Load())
+	assert.Equal(t, int64(10), b.Load())
+}
+
+func TestSubscribeQueueLeave(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	cancel1 := SubscribeQueue(d, "workers", func(ev MyEvent1) {})
+	cancel2 := SubscribeQueue(d, "workers", func(ev MyEvent1) {})
+	grp := d.findGroup(TypeEvent1).(*group[MyEvent1])
+	assert.Len(t, grp.shared, 1)
+	assert.Len(t, grp.shared[0].members, 2)
+
+	cancel1()
+	assert.Len(t, grp.shared[0].members, 1)
+	cancel2()
+	assert.Len(t, grp.shared, 0)
+
+	// No members left, publishing must still work
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), ErrNoSubscribers)
+}
+
+func TestSubscribeQueueSync(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count atomic.Int64
+	for i := 0; i < 3; i++ {
+		defer SubscribeQueue(d, "workers", func(ev MyEvent1) {
+			count.Add(1)
+		})()
+	}
+
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+	assert.Equal(t, int64(1), count.Load())
+}
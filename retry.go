@@ -0,0 +1,241 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// errRetry is returned internally when another attempt of the event is scheduled
+var errRetry = errors.New("event: retry scheduled")
+
+// RetryPolicy represents how the events whose handler failed are retried
+type RetryPolicy struct {
+	MaxAttempts int              // Maximum number of attempts, including the first one
+	Backoff     time.Duration    // Delay before the first retry, doubled on every retry
+	MaxBackoff  time.Duration    // Maximum delay between two attempts, a minute by default
+	Jitter      float64          // Fraction of the delay that is randomized, from 0 to 1
+	RetryIf     func(error) bool // Whether the error is retried, every error by default
+	Ordered     bool             // Hold back the following events while retrying
+}
+
+// maxBackoff is the maximum delay between two attempts, unless specified
+const maxBackoff = time.Minute
+
+// delay returns the delay to wait before the specified retry, starting at one
+func (p RetryPolicy) delay(retry int) time.Duration {
+	limit := p.MaxBackoff
+	if limit <= 0 {
+		limit = maxBackoff
+	}
+
+	delay := p.Backoff
+	for i := 1; i < retry && delay < limit && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+
+	if delay > limit {
+		delay = limit
+	}
+
+	// Randomize the delay, so that the retries of many events are spread out
+	if p.Jitter > 0 && delay > 0 {
+		jitter := time.Duration(math.Min(p.Jitter, 1) * float64(delay))
+		if jitter > 0 {
+			delay -= time.Duration(rand.Int63n(int64(jitter) + 1))
+		}
+	}
+	return delay
+}
+
+// retries returns whether another attempt can be made after the error
+func (p RetryPolicy) retries(attempt int, err error) bool {
+	return attempt < p.MaxAttempts && (p.RetryIf == nil || p.RetryIf(err))
+}
+
+// handle invokes the handler of the consumer, retrying it if the consumer has a
+// retry policy. Events whose publish context expired are dead-lettered instead, if
+// the consumer has dead letters.
+func (s *consumer[T]) handle(g *group[T], msg *message[T]) error {
+	switch {
+	case s.buries() && msg.meta.expired():
+		return s.expire(g, msg)
+	case s.retry.MaxAttempts <= 1:
+		return s.invoke(g, msg)
+	default:
+		return s.retrying(g, msg)
+	}
+}
+
+// retrying invokes the handler until it succeeds, panics or runs out of attempts.
+// If the order of the events does not need to be preserved, the next attempt is
+// scheduled and errRetry is returned so the consumer moves on to the following
+// events, otherwise it waits before the next attempt. It returns the failure of
+// the last attempt, along with the number of attempts made.
+func (s *consumer[T]) retrying(g *group[T], msg *message[T]) (err error) {
+	attempt := 1
+	if msg.meta != nil {
+		attempt += msg.meta.retries
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			failure := newPanicError(g.eventType, s.id, msg.event, r)
+			failure.Attempts = attempt
+			err = failure
+		}
+	}()
+
+	for {
+		if err = s.invoke(g, msg); err == nil {
+			return nil
+		}
+
+		// Stop retrying once out of attempts, or if the consumer was aborted meanwhile
+		if !s.retry.retries(attempt, err) || s.abort.Load() {
+			break
+		}
+
+		if !s.retry.Ordered && s.key == nil {
+			if s.schedule(g, msg, attempt, err) {
+				return errRetry
+			}
+			break // Interrupted meanwhile
+		}
+
+		if !s.wait(s.retry.delay(attempt)) {
+			break
+		}
+		attempt++
+	}
+
+	failure := s.failure(g, msg, err)
+	failure.Attempts = attempt
+	return failure
+}
+
+// wait waits for the delay before the next attempt. It returns false if the retries
+// were interrupted in the meantime, because the consumer unsubscribed or was aborted.
+func (s *consumer[T]) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return !s.abort.Load()
+	case <-s.halt:
+		return false
+	}
+}
+
+// interrupt interrupts the retries of the consumer once it is removed or aborted.
+// The retry being waited for gives up, while the scheduled retries are cancelled
+// and fail with the error of their last attempt. This must be called while holding
+// the lock of the group.
+func (s *consumer[T]) interrupt(g *group[T]) {
+	if s.halt == nil {
+		return
+	}
+
+	select {
+	case <-s.halt: // Already interrupted
+	default:
+		close(s.halt)
+	}
+
+	if len(s.pending) == 0 {
+		return
+	}
+
+	for retry := range s.pending {
+		retry.timer.Stop()
+		s.failures(g, 1)
+		if s.buries() {
+			s.bury(g, retry.msg, retry.failure.Err, retry.failure.Attempts)
+		}
+		retry.msg.meta.complete(retry.failure)
+	}
+
+	// Wake up the consumer, which may be waiting for its retries
+	s.pending = nil
+	g.cond.Broadcast()
+}
+
+// interrupted returns whether the retries of the consumer were interrupted, this
+// must be called while holding the lock of the group.
+func (s *consumer[T]) interrupted() bool {
+	select {
+	case <-s.halt:
+		return true
+	default:
+		return false
+	}
+}
+
+// retrySet represents the retries scheduled for a consumer
+type retrySet[T Event] map[*scheduled[T]]struct{}
+
+// scheduled represents a message waiting for its next attempt
+type scheduled[T Event] struct {
+	msg     message[T]    // Message to queue again
+	failure *HandlerError // Failure of the last attempt
+	timer   *time.Timer   // Timer queueing the message again
+}
+
+// schedule queues the message for the consumer again once the delay before the
+// next attempt has elapsed. The message keeps its waiter, so that a synchronous
+// publisher is only released once the last attempt was made. It returns false if
+// the retries of the consumer were interrupted.
+func (s *consumer[T]) schedule(g *group[T], msg *message[T], attempt int, err error) bool {
+	meta := new(metadata)
+	if msg.meta != nil {
+		*meta = *msg.meta
+	}
+
+	failure := s.failure(g, msg, err)
+	failure.Attempts = attempt
+	retry := &scheduled[T]{msg: *msg, failure: failure}
+	retry.msg.meta = meta
+	retry.msg.meta.retries = attempt
+
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	if s.interrupted() {
+		return false
+	}
+
+	if s.pending == nil {
+		s.pending = make(retrySet[T])
+	}
+
+	// The timer can only fire once the lock is released, after it was assigned
+	s.pending[retry] = struct{}{}
+	retry.timer = time.AfterFunc(s.retry.delay(attempt), func() {
+		g.requeue(s, retry)
+	})
+	return true
+}
+
+// requeue queues a retried message for the consumer again, going through its
+// overflow policy like any published message, unless the retry was cancelled in
+// the meantime.
+func (s *group[T]) requeue(sub *consumer[T], retry *scheduled[T]) {
+	s.cond.L.Lock()
+	if _, ok := sub.pending[retry]; !ok {
+		s.cond.L.Unlock()
+		return
+	}
+
+	delete(sub.pending, retry)
+	var evicted []*consumer[T]
+	if s.deliver(sub, retry.msg, &evicted) == 0 {
+		retry.msg.meta.complete(nil) // Dropped, release the publisher
+	}
+	s.expel(evicted)
+
+	// Wake up the consumer, which may be waiting for its retries
+	s.cond.Broadcast()
+}
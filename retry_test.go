@@ -0,0 +1,284 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	var failures atomic.Int32
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures.Add(1)
+	}))
+	defer d.Close()
+
+	// The handler succeeds on the third attempt
+	var calls atomic.Int32
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if calls.Add(1) < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+	}))()
+
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, int32(0), failures.Load())
+}
+
+func TestRetryExhausted(t *testing.T) {
+	failures := make(chan *HandlerError, 10)
+	d := NewDispatcher(WithOnError(func(err *HandlerError) {
+		failures <- err
+	}))
+	defer d.Close()
+
+	var calls atomic.Int32
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		calls.Add(1)
+		return errors.New("permanent")
+	}, WithRetry(RetryPolicy{MaxAttempts: 2}))()
+
+	assert.Error(t, PublishSync(d, MyEvent1{Number: 1}))
+	assert.Equal(t, int32(2), calls.Load())
+
+	err := <-failures
+	assert.Equal(t, 2, err.Attempts)
+	assert.EqualError(t, err.Err, "permanent")
+}
+
+func TestRetryUnordered(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// The first event fails once, while the others are handled meanwhile
+	var failed atomic.Bool
+	out := make(chan int, 10)
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if ev.Number == 1 && !failed.Swap(true) {
+			return errors.New("transient")
+		}
+		out <- ev.Number
+		return nil
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     50 * time.Millisecond,
+	}))()
+
+	for i := 1; i <= 3; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	assert.Equal(t, 2, <-out)
+	assert.Equal(t, 3, <-out)
+	assert.Equal(t, 1, <-out)
+}
+
+func TestRetryOrdered(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var failed atomic.Bool
+	out := make(chan int, 10)
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if ev.Number == 1 && !failed.Swap(true) {
+			return errors.New("transient")
+		}
+		out <- ev.Number
+		return nil
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+		Jitter:      0.5,
+		Ordered:     true,
+	}))()
+
+	for i := 1; i <= 3; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, i, <-out)
+	}
+}
+
+func TestRetryIf(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// Only the transient errors are retried
+	errPermanent := errors.New("permanent")
+	var calls atomic.Int32
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		calls.Add(1)
+		return errPermanent
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 5,
+		RetryIf: func(err error) bool {
+			return !errors.Is(err, errPermanent)
+		},
+	}))()
+
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{Number: 1}), errPermanent)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryShutdown(t *testing.T) {
+	d := NewDispatcher()
+
+	var calls atomic.Int32
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if calls.Add(1) == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	}, WithRetry(RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     20 * time.Millisecond,
+	}))()
+
+	// Shutdown waits for the scheduled retries
+	Publish(d, MyEvent1{Number: 1})
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	abandoned, err := d.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, abandoned)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryInterrupted(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		for _, shutdown := range []bool{false, true} {
+			d := NewDispatcher()
+			letters := NewDeadLetters(10)
+			var calls atomic.Int32
+			cancel := SubscribeErr(d, func(ev MyEvent1) error {
+				calls.Add(1)
+				return errors.New("transient")
+			}, WithDeadLetterQueue(letters), WithRetry(RetryPolicy{
+				MaxAttempts: 3,
+				Backoff:     time.Hour,
+				Ordered:     ordered,
+			}))
+
+			published := make(chan error, 1)
+			go func() {
+				published <- PublishSync(d, MyEvent1{Number: 1})
+			}()
+			assert.Eventually(t, func() bool {
+				return calls.Load() == 1
+			}, time.Second, time.Millisecond)
+
+			// The next attempt is interrupted by unsubscribing or aborting
+			if shutdown {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				d.Shutdown(ctx)
+				cancel()
+			} else {
+				cancel()
+			}
+
+			// The publisher is released with the failure of the last attempt
+			assert.ErrorContains(t, <-published, "transient")
+			assert.Eventually(t, func() bool {
+				return letters.Len() == 1
+			}, time.Second, time.Millisecond)
+			assert.Equal(t, 1, letters.List()[0].Attempts)
+			assert.EqualError(t, letters.List()[0].Err, "transient")
+			assert.Equal(t, int32(1), calls.Load())
+
+			// Nothing is left to wait for once unsubscribed
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			_, err := d.Shutdown(ctx)
+			assert.NoError(t, err, "ordered=%v shutdown=%v", ordered, shutdown)
+			cancel()
+		}
+	}
+}
+
+func TestRetryOverflow(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	defer d.Close()
+
+	// The first event fails once, while the second one blocks the subscriber
+	letters := NewDeadLetters(10)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var failed atomic.Bool
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		switch {
+		case ev.Number == 1 && !failed.Swap(true):
+			return errors.New("transient")
+		case ev.Number == 2:
+			close(started)
+			<-release
+		}
+		return nil
+	}, WithOverflow(OverflowDropNewest), WithDeadLetterQueue(letters), WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     50 * time.Millisecond,
+	}))()
+
+	Publish(d, MyEvent1{Number: 1})
+	assert.Eventually(t, failed.Load, time.Second, time.Millisecond)
+	Publish(d, MyEvent1{Number: 2})
+	<-started
+	Publish(d, MyEvent1{Number: 3})
+
+	// The retried event does not fit in the full queue, so it is dropped
+	assert.Eventually(t, func() bool {
+		return letters.Len() == 1
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	letter := letters.List()[0]
+	assert.Equal(t, MyEvent1{Number: 1}, letter.Event)
+	assert.ErrorIs(t, letter.Err, ErrDropped)
+	assert.Equal(t, 1, letter.Attempts)
+}
+
+func TestRetryUnsupported(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3}
+	_, err := TrySubscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithRetry(policy))
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.Panics(t, func() {
+		SubscribeBatch(d, func(events []MyEvent1) {}, WithRetry(policy))
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: 10 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, policy.delay(1))
+	assert.Equal(t, 20*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 40*time.Millisecond, policy.delay(3))
+	assert.Equal(t, time.Minute, policy.delay(100))
+
+	policy.MaxBackoff = 25 * time.Millisecond
+	assert.Equal(t, 20*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 25*time.Millisecond, policy.delay(3))
+
+	// Jitter only shortens the delay, by up to the fraction
+	policy.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		delay := policy.delay(1)
+		assert.GreaterOrEqual(t, delay, 8*time.Millisecond)
+		assert.LessOrEqual(t, delay, 10*time.Millisecond)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TypeStats represents a snapshot of the statistics of an event type
+type TypeStats struct {
+	Type         uint32            // Event type
+	Published    uint64            // Number of events published
+	Delivered    uint64            // Number of events handed to the handlers of the subscribers
+	Failed       uint64            // Number of events for which a handler failed or panicked
+	Dropped      uint64            // Number of events dropped or discarded for the subscribers
+	Queued       int               // Number of events currently queued or being processed
+	HighWater    int               // Highest queue length observed for any subscriber
+	Backpressure time.Duration     // Total time publishers were blocked by full queues
+	Subscribers  []SubscriberStats // Statistics of the current subscribers
+}
+
+// SubscriberStats represents a snapshot of the statistics of a subscriber
+type SubscriberStats struct {
+	ID        uint64    // Subscriber identifier
+	Delivered uint64    // Number of events handed to the handler
+	Failed    uint64    // Number of events for which the handler failed or panicked
+	Dropped   uint64    // Number of events dropped or discarded
+	Queued    int       // Number of events currently queued or being processed
+	HighWater int       // Highest queue length observed
+	Duration  Histogram // Distribution of the handler durations, see WithTiming
+}
+
+// Histogram represents a distribution of durations
+type Histogram struct {
+	Bounds []time.Duration // Inclusive upper bounds of the buckets
+	Counts []uint64        // Observations per bucket, the last one being unbounded
+	Count  uint64          // Total number of observations
+	Sum    time.Duration   // Sum of the observed durations
+}
+
+// Stats returns a snapshot of the statistics of every event type, ordered by type.
+// The counters are maintained with atomics and are always enabled, while handler
+// durations are only recorded if the WithTiming option is set.
+func (d *Dispatcher) Stats() []TypeStats {
+	grps := d.subs.Load().grps
+	out := make([]TypeStats, 0, len(grps))
+	for _, grp := range grps {
+		out = append(out, grp.(topic).Stats())
+	}
+	return out
+}
+
+// Stats returns a snapshot of the statistics of the group
+func (s *group[T]) Stats() TypeStats {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+
+	out := TypeStats{
+		Type:         s.eventType,
+		Published:    s.published,
+		Delivered:    s.delivered.Load(),
+		Failed:       s.failed.Load(),
+		Dropped:      s.dropped.Load(),
+		HighWater:    s.peak,
+		Backpressure: s.blocked,
+	}
+
+	for _, sub := range s.subs {
+		out.Queued += len(sub.queue) + int(sub.inflight.Load())
+		if !sub.internal {
+			out.Subscribers = append(out.Subscribers, sub.stats())
+		}
+	}
+
+	// Members of the chain share the queue of its runner
+	if s.chain != nil {
+		runner := s.chain.runner
+		for _, sub := range *s.chain.members.Load() {
+			stats := sub.stats()
+			stats.Queued = len(runner.queue) + int(runner.inflight.Load())
+			stats.HighWater = runner.peak
+			out.Subscribers = append(out.Subscribers, stats)
+		}
+	}
+	return out
+}
+
+// stats returns a snapshot of the statistics of the consumer, this must be called
+// while holding the lock of the group.
+func (s *consumer[T]) stats() SubscriberStats {
+	return SubscriberStats{
+		ID:        s.id,
+		Delivered: s.delivered.Load(),
+		Failed:    s.failed.Load(),
+		Dropped:   s.dropped.Load(),
+		Queued:    len(s.queue) + int(s.inflight.Load()),
+		HighWater: s.peak,
+		Duration:  s.durations.snapshot(),
+	}
+}
+
+// ------------------------------------- Counters -------------------------------------
+
+// counters represents the statistics of a group or of a consumer
+type counters struct {
+	delivered atomic.Uint64 // Events handed to the handlers
+	failed    atomic.Uint64 // Events for which a handler failed
+	dropped   atomic.Uint64 // Events dropped or discarded
+}
+
+// handled records the events handed to the handler of the consumer
+func (s *consumer[T]) handled(g *group[T], n int) {
+	s.delivered.Add(uint64(n))
+	if !s.internal {
+		g.delivered.Add(uint64(n))
+	}
+}
+
+// failures records the events for which the handler of the consumer failed
+func (s *consumer[T]) failures(g *group[T], n int) {
+	s.failed.Add(uint64(n))
+	if !s.internal {
+		g.failed.Add(uint64(n))
+	}
+}
+
+// drop records the events dropped for the consumer
+func (s *consumer[T]) drop(g *group[T], n int) {
+	s.dropped.Add(uint64(n))
+	g.dropped.Add(uint64(n))
+}
+
+// discard discards the events queued for the consumer, releasing any publisher
+// waiting on them.
+func (s *consumer[T]) discard(g *group[T], queue []message[T]) {
+	if len(queue) == 0 {
+		return
+	}
+
+	s.drop(g, len(queue))
+	if s.buries() {
+		for _, msg := range queue {
+			s.bury(g, msg, ErrDropped, msg.meta.attempts())
+		}
+	}
+	discard(queue)
+}
+
+// reject drops an event that could not be queued for the consumer, releasing any
+// publisher waiting on it.
+func (s *consumer[T]) reject(g *group[T], msg message[T]) {
+	s.drop(g, 1)
+	if s.buries() {
+		s.bury(g, msg, ErrDropped, msg.meta.attempts())
+	}
+	msg.meta.reject(ErrDropped)
+}
+
+// ------------------------------------- Histogram -------------------------------------
+
+// durationBounds are the upper bounds of the buckets of the duration histograms
+var durationBounds = [...]time.Duration{
+	time.Microsecond, 5 * time.Microsecond, 10 * time.Microsecond, 50 * time.Microsecond,
+	100 * time.Microsecond, 500 * time.Microsecond, time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 5 * time.Second,
+}
+
+// histogram represents a distribution of durations with fixed buckets
+type histogram struct {
+	counts [len(durationBounds) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+// observe records a duration in the histogram
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(durationBounds) && d > durationBounds[i] {
+		i++
+	}
+
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// snapshot returns a copy of the histogram
+func (h *histogram) snapshot() Histogram {
+	out := Histogram{
+		Bounds: append([]time.Duration(nil), durationBounds[:]...),
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+
+	for i := range h.counts {
+		out.Counts[i] = h.counts[i].Load()
+		out.Count += out.Counts[i]
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	defer Subscribe(d, func(ev MyEvent2) {})()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+	}
+	assert.NoError(t, PublishSync(d, MyEvent2{}))
+
+	stats := d.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, uint32(TypeEvent1), stats[0].Type)
+	assert.Equal(t, uint32(TypeEvent2), stats[1].Type)
+
+	s1 := stats[0]
+	assert.Equal(t, uint64(10), s1.Published)
+	assert.Equal(t, uint64(20), s1.Delivered)
+	assert.Zero(t, s1.Failed)
+	assert.Zero(t, s1.Dropped)
+	assert.Zero(t, s1.Queued)
+	assert.GreaterOrEqual(t, s1.HighWater, 1)
+	assert.Len(t, s1.Subscribers, 2)
+	for _, sub := range s1.Subscribers {
+		assert.NotZero(t, sub.ID)
+		assert.Equal(t, uint64(10), sub.Delivered)
+		assert.Zero(t, sub.Duration.Count)
+	}
+}
+
+func TestStatsFailures(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		if ev.Number%2 == 0 {
+			return errors.New("odd")
+		}
+		return nil
+	})()
+
+	for i := 0; i < 10; i++ {
+		PublishSync(d, MyEvent1{Number: i})
+	}
+
+	stats := d.Stats()[0]
+	assert.Equal(t, uint64(10), stats.Delivered)
+	assert.Equal(t, uint64(5), stats.Failed)
+	assert.Equal(t, uint64(5), stats.Subscribers[0].Failed)
+}
+
+func TestStatsDropped(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	defer d.Close()
+
+	release := make(chan struct{})
+	defer Subscribe(d, func(ev MyEvent1) {
+		<-release
+	}, WithOverflow(OverflowDropNewest))()
+
+	for i := 0; i < 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	stats := d.Stats()[0]
+	assert.Equal(t, uint64(10), stats.Published)
+	assert.NotZero(t, stats.Dropped)
+	assert.Equal(t, stats.Dropped, stats.Subscribers[0].Dropped)
+	assert.NotZero(t, stats.Queued)
+	assert.Equal(t, 1, stats.HighWater)
+	close(release)
+}
+
+func TestStatsBackpressure(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1), WithInterval(time.Millisecond))
+	defer d.Close()
+
+	defer Subscribe(d, func(ev MyEvent1) {
+		time.Sleep(time.Millisecond)
+	})()
+
+	for i := 0; i < 10; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	stats := d.Stats()[0]
+	assert.Greater(t, stats.Backpressure, time.Duration(0))
+}
+
+func TestStatsTiming(t *testing.T) {
+	d := NewDispatcher(WithTiming())
+	defer d.Close()
+
+	defer Subscribe(d, func(ev MyEvent1) {
+		time.Sleep(2 * time.Millisecond)
+	})()
+	defer Subscribe(d, func(ev MyEvent1) {}, WithPriority(1))()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, PublishSync(d, MyEvent1{Number: i}))
+	}
+
+	stats := d.Stats()[0]
+	assert.Len(t, stats.Subscribers, 2)
+
+	slow := stats.Subscribers[0].Duration
+	assert.Equal(t, uint64(5), slow.Count)
+	assert.GreaterOrEqual(t, slow.Sum, 10*time.Millisecond)
+	assert.Len(t, slow.Counts, len(slow.Bounds)+1)
+	for i, bound := range slow.Bounds {
+		if bound < 2*time.Millisecond {
+			assert.Zero(t, slow.Counts[i])
+		}
+	}
+
+	// Members of the ordered chain are timed as well
+	assert.Equal(t, uint64(5), stats.Subscribers[1].Delivered)
+	assert.Equal(t, uint64(5), stats.Subscribers[1].Duration.Count)
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	h.observe(0)
+	h.observe(time.Microsecond)
+	h.observe(2 * time.Microsecond)
+	h.observe(time.Minute)
+
+	out := h.snapshot()
+	assert.Equal(t, uint64(4), out.Count)
+	assert.Equal(t, time.Minute+3*time.Microsecond, out.Sum)
+	assert.Equal(t, uint64(2), out.Counts[0])
+	assert.Equal(t, uint64(1), out.Counts[1])
+	assert.Equal(t, uint64(1), out.Counts[len(out.Counts)-1])
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"fmt"
+)
+
+// TopicInfo describes an event type registered on a dispatcher
+type TopicInfo struct {
+	Type        uint32           // Event type
+	Name        string           // Go type of the event
+	Queued      int              // Number of events queued or being processed
+	Subscribers []SubscriberInfo // Current subscribers of the event type
+}
+
+// SubscriberInfo describes a subscriber of an event type
+type SubscriberInfo struct {
+	ID     uint64 // Subscriber identifier
+	Label  string // Label of the subscriber, see WithLabel
+	Queue  string // Name of the queue group of the subscriber, if any
+	Queued int    // Number of events queued or being processed
+}
+
+// Topics returns a description of every event type registered on the dispatcher,
+// ordered by type, along with their subscribers. This is meant for debugging and
+// for asserting how subscribers are wired, for example in integration tests.
+func (d *Dispatcher) Topics() []TopicInfo {
+	grps := d.subs.Load().grps
+	out := make([]TopicInfo, 0, len(grps))
+	for _, grp := range grps {
+		out = append(out, grp.(topic).Info())
+	}
+	return out
+}
+
+// Info returns a description of the group and of its subscribers
+func (s *group[T]) Info() TopicInfo {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+
+	var event T
+	out := TopicInfo{
+		Type: s.eventType,
+		Name: fmt.Sprintf("%T", event),
+	}
+
+	for _, sub := range s.subs {
+		queued := len(sub.queue) + int(sub.inflight.Load())
+		out.Queued += queued
+		if !sub.internal {
+			out.Subscribers = append(out.Subscribers, sub.info(queued))
+		}
+	}
+
+	// Members of the chain share the queue of its runner
+	if s.chain != nil {
+		runner := s.chain.runner
+		queued := len(runner.queue) + int(runner.inflight.Load())
+		for _, sub := range *s.chain.members.Load() {
+			out.Subscribers = append(out.Subscribers, sub.info(queued))
+		}
+	}
+	return out
+}
+
+// info returns a description of the consumer
+func (s *consumer[T]) info(queued int) SubscriberInfo {
+	out := SubscriberInfo{
+		ID:     s.id,
+		Label:  s.label,
+		Queued: queued,
+	}
+
+	if s.shared != nil {
+		out.Queue = s.shared.name
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopics(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	assert.Empty(t, d.Topics())
+	defer Subscribe(d, func(ev MyEvent2) {}, WithLabel("audit"))()
+	defer Subscribe(d, func(ev MyEvent1) {}, WithLabel("billing"))()
+	defer SubscribeQueue(d, "workers", func(ev MyEvent1) {}, WithLabel("worker"))()
+	defer Subscribe(d, func(ev MyEvent1) {}, WithPriority(1), WithLabel("validate"))()
+
+	topics := d.Topics()
+	assert.Len(t, topics, 2)
+
+	t1 := topics[0]
+	assert.Equal(t, uint32(TypeEvent1), t1.Type)
+	assert.Equal(t, "event.MyEvent1", t1.Name)
+	assert.Zero(t, t1.Queued)
+	assert.Len(t, t1.Subscribers, 3)
+	assert.Equal(t, "billing", t1.Subscribers[0].Label)
+	assert.Equal(t, "worker", t1.Subscribers[1].Label)
+	assert.Equal(t, "workers", t1.Subscribers[1].Queue)
+	assert.Equal(t, "validate", t1.Subscribers[2].Label)
+	for _, sub := range t1.Subscribers {
+		assert.NotZero(t, sub.ID)
+	}
+
+	t2 := topics[1]
+	assert.Equal(t, uint32(TypeEvent2), t2.Type)
+	assert.Equal(t, "event.MyEvent2", t2.Name)
+	assert.Len(t, t2.Subscribers, 1)
+	assert.Equal(t, "audit", t2.Subscribers[0].Label)
+	assert.Empty(t, t2.Subscribers[0].Queue)
+}
+
+func TestTopicsQueued(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	release := make(chan struct{})
+	defer Subscribe(d, func(ev MyEvent1) {
+		<-release
+	})()
+
+	for i := 0; i < 5; i++ {
+		Publish(d, MyEvent1{Number: i})
+	}
+
+	topics := d.Topics()
+	assert.Equal(t, 5, topics[0].Queued)
+	assert.Equal(t, 5, topics[0].Subscribers[0].Queued)
+	close(release)
+}
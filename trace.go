@@ -0,0 +1,56 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+)
+
+// Tracer propagates traces through the dispatcher, so that the handlers of an
+// event are traced as children of the publisher. It can be backed by OpenTelemetry
+// or by any other tracing library. Batch handlers are not traced.
+type Tracer interface {
+	// Capture returns the span context of the publisher from its context, which is
+	// carried along with the queued event. It returns nil if there is none.
+	Capture(ctx context.Context) any
+
+	// Start starts a span around a handler of the event, as a child of the span
+	// context captured when it was published, which may be nil. It returns the
+	// context to pass to the handler, containing the new span.
+	Start(ctx context.Context, parent any, ev Event) (context.Context, Span)
+}
+
+// Span represents a span started by a tracer
+type Span interface {
+	// End ends the span with the outcome of the handler, nil if it succeeded.
+	End(err error)
+}
+
+// capture attaches the span context of the publisher to the metadata, allocating
+// the metadata only if there is a span context to carry.
+func capture(tracer Tracer, meta *metadata) *metadata {
+	ctx := context.Background()
+	if meta != nil && meta.ctx != nil {
+		ctx = meta.ctx
+	}
+
+	span := tracer.Capture(ctx)
+	switch {
+	case span == nil:
+		return meta
+	case meta == nil:
+		return &metadata{span: span}
+	default:
+		meta.span = span
+		return meta
+	}
+}
+
+// parent returns the span context captured when the event was published
+func (m *metadata) parent() any {
+	if m == nil {
+		return nil
+	}
+	return m.span
+}
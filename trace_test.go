@@ -0,0 +1,150 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer(t *testing.T) {
+	tracer := new(recorder)
+	d := NewDispatcher(WithTracer(tracer))
+	defer d.Close()
+
+	// The handler receives the context containing its own span
+	spans := make(chan any, 2)
+	defer SubscribeCtx(context.Background(), d, func(ctx context.Context, ev MyEvent1) {
+		spans <- ctx.Value(spanKey{})
+	})()
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		return errors.New("failed")
+	})()
+
+	ctx := context.WithValue(context.Background(), spanKey{}, "root")
+	PublishCtx(ctx, d, MyEvent1{Number: 1})
+	assert.NotEqual(t, "root", <-spans)
+
+	// Both handlers are traced as children of the publisher
+	recorded := tracer.wait(2)
+	assert.Len(t, recorded, 2)
+	for _, span := range recorded {
+		assert.Equal(t, "root", span.parent)
+		assert.Equal(t, uint32(TypeEvent1), span.event.Type())
+	}
+
+	var errs []string
+	for _, span := range recorded {
+		if span.err != nil {
+			errs = append(errs, span.err.Error())
+		}
+	}
+	assert.Equal(t, []string{"failed"}, errs)
+}
+
+func TestTracerNoParent(t *testing.T) {
+	tracer := new(recorder)
+	d := NewDispatcher(WithTracer(tracer))
+	defer d.Close()
+
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+
+	recorded := tracer.wait(1)
+	assert.Nil(t, recorded[0].parent)
+	assert.Nil(t, recorded[0].err)
+}
+
+func TestTracerPanic(t *testing.T) {
+	tracer := new(recorder)
+	d := NewDispatcher(WithTracer(tracer))
+	defer d.Close()
+
+	Subscribe(d, func(ev MyEvent1) {
+		panic("boom")
+	})
+
+	var failure *HandlerError
+	err := PublishSync(d, MyEvent1{})
+	assert.ErrorAs(t, err, &failure)
+	assert.Equal(t, "boom", failure.Panic)
+
+	recorded := tracer.wait(1)
+	assert.EqualError(t, recorded[0].err, "event: handler panicked: boom")
+}
+
+func TestTracerMiddleware(t *testing.T) {
+	tracer := new(recorder)
+	d := NewDispatcher(WithTracer(tracer))
+	defer d.Close()
+
+	// A publish interceptor may start a span of its own, which is then captured
+	d.Use(Middleware{
+		Publish: func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, ev Event, headers map[string]string) error {
+				return next(context.WithValue(ctx, spanKey{}, "publish"), ev, headers)
+			}
+		},
+	})
+
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	Publish(d, MyEvent1{})
+	assert.Equal(t, "publish", tracer.wait(1)[0].parent)
+}
+
+// ------------------------------------- Recorder -------------------------------------
+
+type spanKey struct{}
+
+// recorder is an in-memory tracer, where the span context is a string
+type recorder struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	owner  *recorder
+	parent any
+	event  Event
+	err    error
+}
+
+func (r *recorder) Capture(ctx context.Context) any {
+	return ctx.Value(spanKey{})
+}
+
+func (r *recorder) Start(ctx context.Context, parent any, ev Event) (context.Context, Span) {
+	span := &recordedSpan{owner: r, parent: parent, event: ev}
+	return context.WithValue(ctx, spanKey{}, fmt.Sprintf("%p", span)), span
+}
+
+func (s *recordedSpan) End(err error) {
+	s.owner.mu.Lock()
+	defer s.owner.mu.Unlock()
+	s.err = err
+	s.owner.spans = append(s.owner.spans, s)
+	if s.owner.cond != nil {
+		s.owner.cond.Broadcast()
+	}
+}
+
+// wait waits until the specified number of spans have ended
+func (r *recorder) wait(n int) []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cond == nil {
+		r.cond = sync.NewCond(&r.mu)
+	}
+
+	for len(r.spans) < n {
+		r.cond.Wait()
+	}
+	return r.spans
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// PublishSync writes an event into the dispatcher and blocks until every current
+// subscriber has processed it. The errors returned by the handlers are joined
+// together. This must not be called from a handler of the same event type, as
+// the subscriber would end up waiting on itself.
+func PublishSync[T Event](broker *Dispatcher, ev T) error {
+	return PublishWait(context.Background(), broker, ev)
+}
+
+// PublishWait writes an event into the dispatcher and blocks until every current
+// subscriber has processed it or the context is done. The errors returned by the
+// handlers are joined together and the context is passed to context-aware handlers.
+// The event is still delivered to the subscribers even if the context expires
+// before they get to process it, except to the subscribers with dead letters which
+// dead-letter it instead, see WithDeadLetter.
+func PublishWait[T Event](ctx context.Context, broker *Dispatcher, ev T) error {
+	group, err := lookup[T](broker, ev.Type())
+	switch {
+	case err == ErrNoSubscribers:
+		return nil
+	case err != nil:
+		return err
+	}
+
+	wait := newWaiter()
+	switch err := group.Publish(message[T]{event: ev, meta: &metadata{ctx: ctx, wait: wait}}, true); err {
+	case nil:
+	case ErrNoSubscribers:
+		return nil
+	default:
+		return err
+	}
+
+	// Release the publisher's own reference and wait for the consumers
+	wait.release()
+	select {
+	case <-wait.ready:
+		return wait.err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ------------------------------------- Waiter -------------------------------------
+
+// waiter waits for the consumers to process a synchronously published event
+type waiter struct {
+	pending atomic.Int64  // Pending deliveries, plus one for the publisher
+	ready   chan struct{} // Closed once all deliveries are complete
+	mu      sync.Mutex    // Protects the errors
+	errs    []error       // Errors collected from the consumers
+}
+
+// newWaiter creates a new waiter, holding a reference for the publisher
+func newWaiter() *waiter {
+	w := &waiter{ready: make(chan struct{})}
+	w.pending.Store(1)
+	return w
+}
+
+// add adds a number of pending deliveries
+func (w *waiter) add(n int) {
+	w.pending.Add(int64(n))
+}
+
+// release releases a pending delivery, closing the waiter once none are left
+func (w *waiter) release() {
+	if w.pending.Add(-1) == 0 {
+		close(w.ready)
+	}
+}
+
+// fail records an error, if any
+func (w *waiter) fail(err error) {
+	if err != nil {
+		w.mu.Lock()
+		w.errs = append(w.errs, err)
+		w.mu.Unlock()
+	}
+}
+
+// err returns the joined errors
+func (w *waiter) err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return errors.Join(w.errs...)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishSync(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	var count atomic.Int64
+	for i := 0; i < 10; i++ {
+		defer Subscribe(d, func(ev MyEvent1) {
+			time.Sleep(time.Millisecond)
+			count.Add(1)
+		})()
+	}
+
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+	assert.Equal(t, int64(10), count.Load())
+}
+
+func TestPublishSyncErrors(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	errFailed := errors.New("failed")
+	defer SubscribeErr(d, func(ev MyEvent1) error {
+		return errFailed
+	})()
+	defer Subscribe(d, func(ev MyEvent1) {
+		panic("boom")
+	}, WithKeepAlive())()
+	defer Subscribe(d, func(ev MyEvent1) {})()
+
+	err := PublishSync(d, MyEvent1{})
+	assert.ErrorIs(t, err, errFailed)
+
+	var failure *HandlerError
+	assert.ErrorAs(t, err, &failure)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestPublishSyncNoSubscribers(t *testing.T) {
+	d := NewDispatcher()
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+
+	Subscribe(d, func(ev MyEvent1) {})()
+	assert.NoError(t, PublishSync(d, MyEvent1{}))
+
+	SubscribeTo(d, TypeEvent2, func(ev MyEvent1) {})
+	assert.ErrorIs(t, PublishSync(d, MyEvent2{}), ErrConflict)
+
+	d.Close()
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{}), ErrClosed)
+}
+
+func TestPublishWaitTimeout(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	Subscribe(d, func(ev MyEvent1) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, PublishWait(ctx, d, MyEvent1{}), context.DeadlineExceeded)
+}
+
+func TestPublishSyncDropped(t *testing.T) {
+	d := NewDispatcher(WithMaxQueue(1))
+	defer d.Close()
+
+	release := make(chan struct{})
+	Subscribe(d, func(ev MyEvent1) {
+		<-release
+	}, WithOverflow(OverflowDropNewest))
+
+	// Fill up the queue so the synchronous event gets dropped
+	for i := 0; i < 3; i++ {
+		Publish(d, MyEvent1{})
+	}
+
+	assert.ErrorIs(t, PublishSync(d, MyEvent1{}), ErrDropped)
+	close(release)
+}
+
+func TestPublishSyncAbandoned(t *testing.T) {
+	d := NewDispatcher()
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{}, 1)
+	Subscribe(d, func(ev MyEvent1) {
+		started <- struct{}{}
+		<-release
+	})
+
+	// Wait until the subscriber is stuck processing the first event
+	Publish(d, MyEvent1{})
+	<-started
+
+	result := make(chan error, 1)
+	go func() {
+		result <- PublishSync(d, MyEvent1{})
+	}()
+
+	// Abandoning the queue must release the waiting publisher
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	d.Shutdown(ctx)
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, ErrDropped)
+	case <-time.After(time.Second):
+		assert.Fail(t, "publisher was not released")
+	}
+}
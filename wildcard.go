@@ -0,0 +1,156 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// wildcard represents a subscription to a range of event types
+type wildcard struct {
+	lo, hi  uint32                             // Range of event types (inclusive)
+	handler func(context.Context, Event) error // Type-erased handler
+	options subscription                       // Subscription options
+	cancels []context.CancelFunc               // Cancellation of the attached consumers
+}
+
+// matches returns whether the event type is within the range of the wildcard
+func (w *wildcard) matches(eventType uint32) bool {
+	return eventType >= w.lo && eventType <= w.hi
+}
+
+// attach adds a consumer for the wildcard to the group. This must be called while
+// holding the dispatcher lock.
+func (w *wildcard) attach(grp topic) {
+	w.cancels = append(w.cancels, grp.Attach(w.handler, w.options))
+}
+
+// SubscribeAll subscribes to every event type, including the event types that are
+// first published or subscribed to after this subscription. See SubscribeRange()
+// for more details.
+func SubscribeAll(broker *Dispatcher, handler func(Event), opts ...SubscribeOption) context.CancelFunc {
+	return SubscribeRange(broker, 0, math.MaxUint32, handler, opts...)
+}
+
+// SubscribeRange subscribes to every event type within the range [lo, hi], including
+// the event types that are first published or subscribed to after this subscription.
+// The events are received through the Event interface.
+//
+// A separate subscriber is created for each matching event type, hence the handler
+// may be called concurrently for events of different types, while the events of a
+// single type are still received in order. Since the subscription spans several
+// event types, the predicate of WithFilter and the key of WithOrderingKey must be
+// given on the Event interface, otherwise it panics with ErrUnsupported.
+func SubscribeRange(broker *Dispatcher, lo, hi uint32, handler func(Event), opts ...SubscribeOption) context.CancelFunc {
+	cancel, err := TrySubscribeRange(broker, lo, hi, handler, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return cancel
+}
+
+// TrySubscribeRange subscribes to every event type within the range like
+// SubscribeRange, but returns an error instead of panicking. It returns ErrClosed
+// if the dispatcher is closed and ErrUnsupported if the options can not be combined.
+func TrySubscribeRange(broker *Dispatcher, lo, hi uint32, handler func(Event), opts ...SubscribeOption) (context.CancelFunc, error) {
+	w := &wildcard{
+		lo: lo, hi: hi,
+		options: newSubscription(opts),
+		handler: func(_ context.Context, ev Event) error {
+			handler(ev)
+			return nil
+		},
+	}
+
+	// Reject the options up front, rather than when an event type is attached
+	if err := w.options.validate(); err != nil {
+		return nil, err
+	}
+	if err := w.options.untyped(); err != nil {
+		return nil, err
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.isClosed() {
+		return nil, ErrClosed
+	}
+
+	// Attach to all of the existing groups within the range
+	reg := broker.subs.Load()
+	for i, eventType := range reg.keys {
+		if w.matches(eventType) {
+			w.attach(reg.grps[i].(topic))
+		}
+	}
+
+	// Copy-on-write: register the wildcard for the groups created later
+	old := *broker.wild.Load()
+	wild := make([]*wildcard, 0, len(old)+1)
+	wild = append(wild, old...)
+	wild = append(wild, w)
+	broker.wild.Store(&wild)
+
+	return func() {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+
+		// Copy-on-write: unregister the wildcard
+		old := *broker.wild.Load()
+		wild := make([]*wildcard, 0, len(old))
+		for _, v := range old {
+			if v != w {
+				wild = append(wild, v)
+			}
+		}
+		broker.wild.Store(&wild)
+
+		// Cancel all of the attached consumers
+		for _, cancel := range w.cancels {
+			cancel()
+		}
+		w.cancels = nil
+	}, nil
+}
+
+// untyped returns ErrUnsupported if the subscription has typed options which do
+// not accept every event type, as required by wildcard subscriptions.
+func (s *subscription) untyped() error {
+	if _, ok := s.filter.(func(Event) bool); s.filter != nil && !ok {
+		return fmt.Errorf("%w: wildcard filters must accept any Event", ErrUnsupported)
+	}
+	if _, ok := s.key.(func(Event) uint64); s.key != nil && !ok {
+		return fmt.Errorf("%w: wildcard ordering keys must accept any Event", ErrUnsupported)
+	}
+	return nil
+}
+
+// matchGroup creates the group for the event type if a wildcard subscription matches
+// it, so that wildcard subscribers receive event types nobody subscribed to yet. It
+// returns nil if no wildcard matches or the dispatcher is closed.
+func matchGroup[T Event](broker *Dispatcher, eventType uint32) *group[T] {
+	if !broker.matches(eventType) {
+		return nil
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.isClosed() {
+		return nil
+	}
+
+	return groupFor[T](broker, eventType)
+}
+
+// matches returns whether any of the wildcard subscriptions matches the event type
+func (d *Dispatcher) matches(eventType uint32) bool {
+	for _, w := range *d.wild.Load() {
+		if w.matches(eventType) {
+			return true
+		}
+	}
+	return false
+}
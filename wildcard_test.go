@@ -0,0 +1,153 @@
+// Copyright (c) Roman Atachiants and contributore. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for detaile.
+
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeAll(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// Existing subscription to a type
+	defer Subscribe(d, func(ev MyEvent1) {})()
+
+	var mu sync.Mutex
+	var received []Event
+	cancel := SubscribeAll(d, func(ev Event) {
+		mu.Lock()
+		received = append(received, ev)
+		mu.Unlock()
+	})
+
+	// Event type registered before the wildcard, and one nobody subscribed to
+	Publish(d, MyEvent1{Number: 1})
+	Publish(d, MyEvent2{Text: "hello"})
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	assert.ElementsMatch(t, []Event{MyEvent1{Number: 1}, MyEvent2{Text: "hello"}}, received)
+	mu.Unlock()
+
+	// Once cancelled, the wildcard no longer receives anything
+	cancel()
+	assert.Equal(t, 1, d.count(TypeEvent1))
+	assert.Equal(t, 0, d.count(TypeEvent2))
+	assert.ErrorIs(t, TryPublish(d, MyEvent3{ID: 99}), ErrNoSubscribers)
+}
+
+func TestSubscribeAllFilter(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// The predicate and the ordering key are given on the Event interface
+	received := make(chan Event, 10)
+	defer SubscribeAll(d, func(ev Event) {
+		received <- ev
+	}, WithFilter(func(ev Event) bool {
+		return ev.Type() != TypeEvent1
+	}), WithOrderingKey(func(ev Event) uint64 {
+		return uint64(ev.Type())
+	}), WithConcurrency(2))()
+
+	PublishSync(d, MyEvent1{Number: 1})
+	Publish(d, MyEvent2{Text: "hello"})
+	assert.Equal(t, MyEvent2{Text: "hello"}, <-received)
+	assert.Empty(t, received)
+}
+
+func TestSubscribeAllTyped(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	// Typed options can not apply to every event type
+	assert.PanicsWithError(t, "event: unsupported combination of options: wildcard filters must accept any Event", func() {
+		SubscribeAll(d, func(ev Event) {}, WithFilter(func(ev MyEvent1) bool {
+			return true
+		}))
+	})
+	assert.Panics(t, func() {
+		SubscribeAll(d, func(ev Event) {}, WithOrderingKey(func(ev MyEvent1) uint64 {
+			return 0
+		}))
+	})
+
+	_, err := TrySubscribeRange(d, 0, 10, func(ev Event) {}, WithFilter(func(ev MyEvent1) bool {
+		return true
+	}))
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	// Nothing was registered, so publishing still works
+	defer Subscribe(d, func(ev MyEvent1) {})()
+	assert.NotPanics(t, func() {
+		Publish(d, MyEvent2{Text: "hello"})
+	})
+	assert.NoError(t, PublishSync(d, MyEvent1{Number: 1}))
+}
+
+func TestSubscribeRange(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	received := make(chan Event, 100)
+	defer SubscribeRange(d, 10, 20, func(ev Event) {
+		received <- ev
+	})()
+
+	// Subscribed later, the wildcard must still receive it
+	defer SubscribeTo(d, 15, func(ev MyEvent3) {})()
+
+	for id := 0; id < 30; id++ {
+		Publish(d, MyEvent3{ID: id})
+	}
+
+	for id := 10; id <= 20; id++ {
+		select {
+		case ev := <-received:
+			assert.GreaterOrEqual(t, ev.Type(), uint32(10))
+			assert.LessOrEqual(t, ev.Type(), uint32(20))
+		case <-time.After(time.Second):
+			assert.Fail(t, "missing event")
+		}
+	}
+
+	assert.Equal(t, 0, d.count(5))
+	assert.Equal(t, 2, d.count(15))
+}
+
+func TestSubscribeRangeConflict(t *testing.T) {
+	d := NewDispatcher()
+	defer d.Close()
+
+	defer SubscribeAll(d, func(ev Event) {})()
+	Publish(d, MyEvent1{})
+
+	// Group was created with the type of the publisher
+	assert.Panics(t, func() {
+		SubscribeTo(d, TypeEvent1, func(ev MyEvent2) {})
+	})
+}
+
+func TestSubscribeRangeClosed(t *testing.T) {
+	d := NewDispatcher()
+	defer SubscribeAll(d, func(ev Event) {})()
+	d.Close()
+
+	assert.ErrorIs(t, TryPublish(d, MyEvent1{}), ErrClosed)
+	assert.Panics(t, func() {
+		SubscribeAll(d, func(ev Event) {})
+	})
+
+	_, err := TrySubscribeRange(d, 0, 10, func(ev Event) {})
+	assert.ErrorIs(t, err, ErrClosed)
+}